@@ -12,15 +12,6 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// getEnv returns an env var's value, or a default.
-func getEnv(key string, _default string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
-
-	return _default
-}
-
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage: dstask-import github|tw|--help|help")
 	fmt.Fprintln(os.Stderr, "")
@@ -45,25 +36,33 @@ func main() {
 	case "--help", "help":
 		usage()
 	case "tw":
-		conf := dstask.NewConfig()
+		conf, err := dstask.NewConfig()
+		if err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 		if err := tw.Do(conf); err != nil {
 			dstask.ExitFail(err.Error())
 		}
 	case "github":
+		conf, err := dstask.NewConfig()
+		if err != nil {
+			logrus.Fatal(err.Error())
+		}
+
 		// Determine platform-safe default paths
 		home, err := os.UserHomeDir()
 		if err != nil {
 			home = os.Getenv("HOME")
 		}
-		repo := getEnv("DSTASK_GIT_REPO", filepath.Join(home, ".dstask"))
 		configFile := filepath.Join(home, ".dstask-import.toml")
 
-		cfg, err := config.Load(configFile, repo)
+		cfg, err := config.Load(configFile, conf.Repo)
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
 
-		err = github.Do(repo, cfg)
+		err = github.Do(conf, cfg)
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
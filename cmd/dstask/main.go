@@ -3,14 +3,24 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/naggie/dstask"
 	"github.com/naggie/dstask/completions"
 )
 
 func main() {
-	query := dstask.ParseQuery(os.Args[1:]...)
+	// global options are accepted anywhere, and removed before parsing, as
+	// some commands read os.Args themselves
+	args, opts, err := dstask.CutOptions(os.Args[1:])
+	if err != nil {
+		dstask.ExitFail("%s", err)
+	}
+
+	os.Args = append(os.Args[:1], args...)
+	dstask.COLOR = opts.Color
+	dstask.FORMAT = opts.Format
+
+	query := mustParseQuery(os.Args[1:]...)
 
 	// It will remain true if we handle a command that doesn't require
 	// initialisation
@@ -41,21 +51,47 @@ func main() {
 		return
 	}
 
-	conf := dstask.NewConfig()
-	dstask.EnsureRepoExists(conf.Repo)
+	conf := mustNewConfig()
+	conf.ApplyDisplaySettings()
+
+	defer func() {
+		if err := dstask.CloseStores(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}()
+
+	if opts.Template != "" {
+		dstask.TEMPLATE, err = dstask.ParseOutputTemplate(conf, opts.Template)
+		if err != nil {
+			dstask.ExitFail("%s", err)
+		}
+
+		dstask.FORMAT = dstask.FORMAT_TEMPLATE
+	}
+	mustEnsureRepoExists(conf.Repo)
 
 	// Load state for getting and setting ctx
-	state := dstask.LoadState(conf.StateFile)
-	ctx := state.Context
+	state := mustLoadState(conf.StateFile)
+
+	ctx, err := state.ActiveContext(conf.Repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// The default context of the config file applies until a context is set
+	// on this machine.
+	if _, err := os.Stat(conf.StateFile); os.IsNotExist(err) && conf.DefaultContext != "" {
+		ctx = mustParseContext(conf.DefaultContext)
+	}
 
 	// Check if we have a context override.
 	if conf.CtxFromEnvVar != "" {
-		if query.Cmd == dstask.CMD_CONTEXT && len(os.Args) >= 3 {
+		if query.Cmd == dstask.CMD_CONTEXT && len(os.Args) >= 3 &&
+			os.Args[2] != "list" && os.Args[2] != "save" {
 			dstask.ExitFail("setting context not allowed while DSTASK_CONTEXT is set")
 		}
 
-		splitted := strings.Fields(conf.CtxFromEnvVar)
-		ctx = dstask.ParseQuery(splitted...)
+		ctx = mustParseContext(conf.CtxFromEnvVar)
 	}
 
 	// Check if we ignore context with the "--" token
@@ -63,6 +99,17 @@ func main() {
 		ctx = dstask.Query{}
 	}
 
+	// With auto sync, commands that commit are followed by a sync.
+	autoSync := conf.AutoSync &&
+		query.Cmd != dstask.CMD_SYNC &&
+		query.Cmd != dstask.CMD_GIT &&
+		query.Cmd != dstask.CMD_COMPLETIONS
+
+	var head string
+	if autoSync {
+		head = dstask.GitHead(conf.Repo)
+	}
+
 	switch query.Cmd {
 	// The default command
 	case "", dstask.CMD_NEXT, dstask.CMD_SHOW_NEXT:
@@ -90,6 +137,16 @@ func main() {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_RECUR:
+		if err := dstask.CommandRecur(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_GENERATE:
+		if err := dstask.CommandGenerate(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_LOG:
 		if err := dstask.CommandLog(conf, ctx, query); err != nil {
 			dstask.ExitFail(err.Error())
@@ -110,11 +167,36 @@ func main() {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_DEFER:
+		if err := dstask.CommandDefer(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_DELEGATE:
+		if err := dstask.CommandDelegate(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_UNDELEGATE:
+		if err := dstask.CommandUndelegate(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_CONTEXT:
 		if err := dstask.CommandContext(conf, state, ctx, query); err != nil {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_PROJECT:
+		if err := dstask.CommandProject(conf); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_REPORT:
+		if err := dstask.CommandReport(conf, ctx); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_MODIFY:
 		if err := dstask.CommandModify(conf, ctx, query); err != nil {
 			dstask.ExitFail(err.Error())
@@ -130,18 +212,43 @@ func main() {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_SUBTASK:
+		if err := dstask.CommandSubtask(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_SUBTASKS:
+		if err := dstask.CommandSubtasks(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_CHECK:
+		if err := dstask.CommandCheck(conf, ctx, query, true); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_UNCHECK:
+		if err := dstask.CommandCheck(conf, ctx, query, false); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_UNDO:
 		if err := dstask.CommandUndo(conf, os.Args, ctx, query); err != nil {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_FSCK:
+		if err := dstask.CommandFsck(conf, os.Args[2:]); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_SYNC:
 		if err := dstask.CommandSync(conf.Repo); err != nil {
 			dstask.ExitFail(err.Error())
 		}
 
 	case dstask.CMD_GIT:
-		dstask.MustRunGitCmd(conf.Repo, os.Args[2:]...)
+		mustRunGitCmd(conf.Repo, os.Args[2:]...)
 
 	case dstask.CMD_SHOW_ACTIVE:
 		if err := dstask.CommandShowActive(conf, ctx, query); err != nil {
@@ -173,6 +280,26 @@ func main() {
 			dstask.ExitFail(err.Error())
 		}
 
+	case dstask.CMD_SHOW_DELEGATED:
+		if err := dstask.CommandShowDelegated(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_SHOW_OVERDUE:
+		if err := dstask.CommandShowOverdue(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_SHOW_DEFERRED:
+		if err := dstask.CommandShowDeferred(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
+	case dstask.CMD_SHOW_RECURRING:
+		if err := dstask.CommandShowRecurring(conf, ctx, query); err != nil {
+			dstask.ExitFail(err.Error())
+		}
+
 	case dstask.CMD_SHOW_RESOLVED:
 		if err := dstask.CommandShowResolved(conf, ctx, query); err != nil {
 			dstask.ExitFail(err.Error())
@@ -189,4 +316,66 @@ func main() {
 	default:
 		panic("this should never happen?")
 	}
+
+	if autoSync && dstask.GitHead(conf.Repo) != head {
+		mustSync(conf.Repo)
+	}
+}
+
+// the dstask package returns errors, leaving it to the command to exit on
+// them. These exit with the error, for errors the command can't recover from.
+
+func mustParseQuery(args ...string) dstask.Query {
+	query, err := dstask.ParseQuery(args...)
+	if err != nil {
+		dstask.ExitFail("%s", err)
+	}
+
+	return query
+}
+
+func mustParseContext(context string) dstask.Query {
+	ctx, err := dstask.ParseContext(context)
+	if err != nil {
+		dstask.ExitFail("invalid context %q: %s", context, err)
+	}
+
+	return ctx
+}
+
+func mustNewConfig() dstask.Config {
+	conf, err := dstask.NewConfig()
+	if err != nil {
+		dstask.ExitFail("%s", err)
+	}
+
+	return conf
+}
+
+func mustEnsureRepoExists(repoPath string) {
+	if err := dstask.EnsureRepoExists(repoPath); err != nil {
+		dstask.ExitFail("%s", err)
+	}
+}
+
+func mustLoadState(stateFilePath string) dstask.State {
+	state, err := dstask.LoadState(stateFilePath)
+	if err != nil {
+		dstask.ExitFail("%s", err)
+	}
+
+	return state
+}
+
+// mustRunGitCmd runs git, which reports its own errors.
+func mustRunGitCmd(repoPath string, args ...string) {
+	if err := dstask.RunGitCmd(repoPath, args...); err != nil {
+		dstask.ExitFail("Failed to run git cmd.")
+	}
+}
+
+func mustSync(repoPath string) {
+	if err := dstask.Sync(repoPath); err != nil {
+		dstask.ExitFail("%s", err)
+	}
 }
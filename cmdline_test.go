@@ -4,10 +4,23 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// mustParseQuery parses a command line, failing the test on error.
+func mustParseQuery(t *testing.T, args ...string) Query {
+	t.Helper()
+
+	query, err := ParseQuery(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return query
+}
+
 func TestParseQuery(t *testing.T) {
 	type testCase struct {
 		input    []string
@@ -156,6 +169,23 @@ func TestParseQuery(t *testing.T) {
 				Note:          "Test Note",
 			},
 		},
+		// delegate names are case-preserving
+		{
+			[]string{"show-delegated", "delegated:Alice", "+urgent"},
+			Query{
+				Cmd:           "show-delegated",
+				IDs:           nil,
+				Tags:          []string{"urgent"},
+				AntiTags:      nil,
+				Project:       "",
+				AntiProjects:  nil,
+				DelegatedTo:   "Alice",
+				Template:      0,
+				Text:          "",
+				IgnoreContext: false,
+				Note:          "",
+			},
+		},
 	} // end test cases
 
 	for i, tc := range tests {
@@ -164,8 +194,111 @@ func TestParseQuery(t *testing.T) {
 		t.Run(fmt.Sprintf("test %v: %s", i, description), func(t *testing.T) {
 			t.Parallel()
 
-			actual := ParseQuery(tc.input...)
+			actual := mustParseQuery(t, tc.input...)
 			assert.Equal(t, tc.expected, actual)
 		})
 	}
 }
+
+func TestParseQueryDueRange(t *testing.T) {
+	query := mustParseQuery(t, "next", "due.before:2025-07-31", "due.after:2025-07-01")
+
+	assert.Equal(t, "after", query.DateFilter)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local), query.Due)
+	assert.Equal(t, time.Date(2025, 7, 31, 0, 0, 0, 0, time.Local), query.DueBefore)
+	assert.Equal(t, "due.after:2025-07-01 due.before:2025-07-31", query.String())
+
+	// round trip
+	assert.Equal(t, query, mustParseQuery(t, append([]string{"next"}, strings.Fields(query.String())...)...))
+
+	assert.Equal(t, "due:overdue", mustParseQuery(t, "due:overdue").String())
+}
+
+func TestParseQuerySort(t *testing.T) {
+	query := mustParseQuery(t, "next", "+work", "sort:due,-Priority,project")
+
+	assert.Equal(t, []SortKey{
+		{Field: "due"},
+		{Field: "priority", Descending: true},
+		{Field: "project"},
+	}, query.Sort)
+	assert.Equal(t, []string{"work"}, query.Tags)
+	assert.Equal(t, "+work sort:due,-priority,project", query.String())
+
+	// round trip
+	assert.Equal(t, query, mustParseQuery(t, append([]string{"next"}, strings.Fields(query.String())...)...))
+
+	// the sort order of the query wins over that of the context
+	ctx := mustParseQuery(t, "sort:summary")
+	merged, err := query.Merge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, query.Sort, merged.Sort)
+
+	query = mustParseQuery(t, "+home")
+	merged, err = query.Merge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, ctx.Sort, merged.Sort)
+
+	assert.Error(t, mustParseQuery(t, "add", "sort:due", "x").checkSettable())
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"next", "due:someday"},
+		{"next", "due:today", "due:tomorrow"},
+		{"next", "priority<P9"},
+		{"add", "x", "dep:first"},
+		{"next", "sort:colour"},
+		{"next", "recur:sometimes"},
+		{"next", "(+bug", "or", "priority>=P7)"},
+	} {
+		_, err := ParseQuery(args...)
+		assert.Error(t, err, strings.Join(args, " "))
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	query := mustParseQuery(t, "add", "x", "project:web")
+
+	_, err := query.Merge(mustParseQuery(t, "project:infra"))
+	assert.Error(t, err)
+
+	merged, err := query.Merge(mustParseQuery(t, "+work"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"work"}, merged.Tags)
+}
+
+func TestMergeOverdue(t *testing.T) {
+	query := mustParseQuery(t, "due:overdue")
+
+	merged, err := query.Merge(mustParseQuery(t, "due:overdue"))
+	assert.NoError(t, err)
+	assert.Equal(t, "overdue", merged.DateFilter)
+
+	_, err = query.Merge(mustParseQuery(t, "due:today"))
+	assert.Error(t, err)
+}
+
+func TestMergeSettableContext(t *testing.T) {
+	query := mustParseQuery(t, "add", "x")
+
+	merged, err := query.Merge(mustParseQuery(t, "+work", "priority<=P1", "due.before:friday", "sort:due").settable())
+	assert.NoError(t, err)
+	assert.NoError(t, merged.checkSettable())
+	assert.Equal(t, []string{"work"}, merged.Tags)
+	assert.Empty(t, merged.Priority)
+	assert.True(t, merged.Due.IsZero())
+
+	merged, err = query.Merge(mustParseQuery(t, "delegated:alice").settable())
+	assert.NoError(t, err)
+	assert.NoError(t, merged.checkSettable())
+
+	merged, err = query.Merge(mustParseQuery(t, "(+bug", "or", "+ops)").settable())
+	assert.NoError(t, err)
+	assert.NoError(t, merged.checkSettable())
+
+	merged, err = query.Merge(mustParseQuery(t, "P1", "due.on:friday").settable())
+	assert.NoError(t, err)
+	assert.Equal(t, PRIORITY_HIGH, merged.Priority)
+	assert.False(t, merged.Due.IsZero())
+}
@@ -0,0 +1,94 @@
+package dstask
+
+// every ANSI escape sequence dstask writes is made here, so that none are
+// written unless colour is enabled for the file written to.
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	COLOR_AUTO   = "auto"
+	COLOR_ALWAYS = "always"
+	COLOR_NEVER  = "never"
+
+	SGR_RESET = "\033[0m"
+	// reverse video, and back, eg. of search terms
+	SGR_REVERSE     = "\033[7m"
+	SGR_REVERSE_OFF = "\033[27m"
+)
+
+var COLOR_MODES = []string{COLOR_AUTO, COLOR_ALWAYS, COLOR_NEVER}
+
+// colourEnabled returns true if escape sequences are to be written to the
+// given file. With auto, the default, they are written to terminals only.
+func colourEnabled(f *os.File) bool {
+	switch COLOR {
+	case COLOR_ALWAYS:
+		return true
+	case COLOR_NEVER:
+		return false
+	}
+
+	if f == os.Stdout {
+		return StdoutIsTTY()
+	}
+
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// noColorSet returns true if the user asked for no colours, see
+// https://no-color.org. Only --color=always overrides it.
+func noColorSet() bool {
+	return os.Getenv("NO_COLOR") != "" && COLOR != COLOR_ALWAYS
+}
+
+// sgr returns the escape sequence to set the mode and colours of text.
+// Colours are of the palette of the theme, or COLOUR_NONE.
+func sgr(mode, fg, bg int) string {
+	params := []string{strconv.Itoa(mode)}
+
+	if fg != COLOUR_NONE {
+		params = append(params, colourParam(fg, 30, 38))
+	}
+
+	if bg != COLOUR_NONE {
+		params = append(params, colourParam(bg, 40, 48))
+	}
+
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// colourParam returns the SGR parameter of a colour, given the parameter of
+// the first of the 8 basic colours, and of the 256-colour palette.
+func colourParam(colour, basic, extended int) string {
+	if PALETTE == 16 {
+		if colour < 8 {
+			return strconv.Itoa(basic + colour)
+		}
+
+		// bright colours
+		return strconv.Itoa(basic + 60 + colour - 8)
+	}
+
+	return fmt.Sprintf("%d;5;%d", extended, colour)
+}
+
+// paint returns text in the given colour, if colour is enabled for f.
+func paint(f *os.File, fg int, text string) string {
+	if !colourEnabled(f) {
+		return text
+	}
+
+	return sgr(MODE_DEFAULT, fg, COLOUR_NONE) + text + SGR_RESET
+}
+
+// printPainted prints a line in the given colour to stdout.
+func printPainted(fg int, format string, a ...any) {
+	fmt.Println(paint(os.Stdout, fg, fmt.Sprintf(format, a...)))
+}
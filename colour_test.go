@@ -0,0 +1,30 @@
+package dstask
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSgr(t *testing.T) {
+	defer THEMES[DEFAULT_THEME].apply()
+
+	THEMES[THEME_DARK_256].apply()
+	assert.Equal(t, "\033[4;38;5;160;48;5;233m", sgr(MODE_UNDERLINE, 160, 233))
+	assert.Equal(t, "\033[0;38;5;245m", sgr(0, 245, COLOUR_NONE))
+
+	THEMES[THEME_16].apply()
+	assert.Equal(t, "\033[0;31;100m", sgr(0, 1, 8))
+	assert.Equal(t, "\033[7m", sgr(MODE_REVERSE, COLOUR_NONE, COLOUR_NONE))
+}
+
+func TestPaint(t *testing.T) {
+	defer func(color string) { COLOR = color }(COLOR)
+
+	COLOR = COLOR_NEVER
+	assert.Equal(t, "text", paint(os.Stdout, 1, "text"))
+
+	COLOR = COLOR_ALWAYS
+	assert.Equal(t, "\033[0;38;5;1mtext\033[0m", paint(os.Stdout, 1, "text"))
+}
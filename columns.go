@@ -0,0 +1,165 @@
+package dstask
+
+// columns of the task list and reports, selectable in the config file.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	COLUMN_ID       = "id"
+	COLUMN_PRIORITY = "priority"
+	COLUMN_TAGS     = "tags"
+	COLUMN_DUE      = "due"
+	COLUMN_PROJECT  = "project"
+	// only shown if a task has subtasks
+	COLUMN_PROGRESS = "progress"
+	COLUMN_URGENCY  = "urgency"
+	COLUMN_SUMMARY  = "summary"
+	COLUMN_CREATED  = "created"
+	COLUMN_RESOLVED = "resolved"
+	COLUMN_AGE      = "age"
+	COLUMN_STATUS   = "status"
+	// number of lines of notes
+	COLUMN_NOTES = "notes"
+	// first 8 characters
+	COLUMN_UUID = "uuid"
+)
+
+var DEFAULT_COLUMNS = []string{
+	COLUMN_ID,
+	COLUMN_PRIORITY,
+	COLUMN_TAGS,
+	COLUMN_DUE,
+	COLUMN_PROJECT,
+	COLUMN_PROGRESS,
+	COLUMN_SUMMARY,
+}
+
+// columns of show-resolved, by week
+var WEEK_COLUMNS = []string{
+	COLUMN_RESOLVED,
+	COLUMN_PRIORITY,
+	COLUMN_TAGS,
+	COLUMN_DUE,
+	COLUMN_PROJECT,
+	COLUMN_SUMMARY,
+}
+
+type taskColumn struct {
+	header string
+	value  func(t *Task) string
+}
+
+var taskColumns = map[string]taskColumn{
+	COLUMN_ID: {
+		header: "ID",
+		// id should be at least 2 chars wide to match column header
+		// (headers can be truncated)
+		value: func(t *Task) string { return fmt.Sprintf("%-2d", t.ID) },
+	},
+	COLUMN_PRIORITY: {
+		header: "Priority",
+		value:  func(t *Task) string { return t.Priority },
+	},
+	COLUMN_TAGS: {
+		header: "Tags",
+		value:  func(t *Task) string { return strings.Join(t.Tags, " ") },
+	},
+	COLUMN_DUE: {
+		header: "Due",
+		value:  func(t *Task) string { return t.ParseDueDateToStr() },
+	},
+	COLUMN_PROJECT: {
+		header: "Project",
+		value:  func(t *Task) string { return t.Project },
+	},
+	COLUMN_PROGRESS: {
+		header: "Progress",
+		value:  func(t *Task) string { return t.SubtaskProgress() },
+	},
+	COLUMN_URGENCY: {
+		header: "Urgency",
+		value:  func(t *Task) string { return fmt.Sprintf("%.1f", t.Urgency) },
+	},
+	COLUMN_SUMMARY: {
+		header: "Summary",
+		value:  func(t *Task) string { return t.LongSummary() },
+	},
+	COLUMN_CREATED: {
+		header: "Created",
+		value:  func(t *Task) string { return formatDate(t.Created) },
+	},
+	COLUMN_RESOLVED: {
+		header: "Resolved",
+		value:  func(t *Task) string { return formatDate(t.Resolved) },
+	},
+	COLUMN_AGE: {
+		header: "Age",
+		value:  func(t *Task) string { return formatAge(time.Since(t.Created)) },
+	},
+	COLUMN_STATUS: {
+		header: "Status",
+		value:  func(t *Task) string { return t.Status },
+	},
+	COLUMN_NOTES: {
+		header: "Notes",
+		value: func(t *Task) string {
+			if t.Notes == "" {
+				return ""
+			}
+
+			return strconv.Itoa(strings.Count(strings.TrimRight(t.Notes, "\n"), "\n") + 1)
+		},
+	},
+	COLUMN_UUID: {
+		header: "UUID",
+		value:  func(t *Task) string { return t.UUID[:min(8, len(t.UUID))] },
+	},
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("Mon 2 Jan 2006")
+}
+
+// formatAge formats a duration in the largest unit that fits, eg. 3w
+func formatAge(d time.Duration) string {
+	days := int(d.Hours() / 24)
+
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case days < 1:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case days < 14:
+		return fmt.Sprintf("%dd", days)
+	case days < 90:
+		return fmt.Sprintf("%dw", days/7)
+	case days < 730:
+		return fmt.Sprintf("%dmo", days/30)
+	default:
+		return fmt.Sprintf("%dy", days/365)
+	}
+}
+
+func validateColumns(columns []string) error {
+	if len(columns) == 0 {
+		return errors.New("no columns given")
+	}
+
+	for _, name := range columns {
+		if _, ok := taskColumns[name]; !ok {
+			return fmt.Errorf("invalid column: %s", name)
+		}
+	}
+
+	return nil
+}
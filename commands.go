@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
@@ -16,11 +18,11 @@ func CommandAdd(conf Config, ctx, query Query) error {
 	if query.Text == "" && query.Template == 0 {
 		return errors.New("task description or template required")
 	}
-	if query.DateFilter != "" && query.DateFilter != "in" && query.DateFilter != "on" {
-		return errors.New("cannot use date filter with add command")
+	if err := query.checkSettable(); err != nil {
+		return err
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
@@ -28,8 +30,15 @@ func CommandAdd(conf Config, ctx, query Query) error {
 	if query.Template > 0 {
 		var taskSummary string
 
-		tt := ts.MustGetByID(query.Template)
-		query = query.Merge(ctx)
+		tt, err := ts.GetByID(query.Template)
+		if err != nil {
+			return err
+		}
+
+		query, err = query.Merge(ctx.settable())
+		if err != nil {
+			return err
+		}
 
 		if query.Text != "" {
 			taskSummary = query.Text
@@ -51,10 +60,22 @@ func CommandAdd(conf Config, ctx, query Query) error {
 
 		// Modify the task with any tags/projects/antiProjects/priorities/dueDates in query
 		task.Modify(query)
+		if err := ts.ApplyDependencies(&task, query); err != nil {
+			return err
+		}
+
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.SavePendingChanges(); err != nil {
+			return err
+		}
 
-		task = ts.MustLoadTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Added %s", task)
+		if err := conf.Commit(CMD_ADD, &task, "Added %s", task); err != nil {
+			return err
+		}
 
 		if tt.Status != STATUS_TEMPLATE {
 			// Insert Text Statement to inform user of real Templates
@@ -64,7 +85,11 @@ func CommandAdd(conf Config, ctx, query Query) error {
 		}
 	} else if query.Text != "" {
 		ctx.PrintContextDescription()
-		query = query.Merge(ctx)
+		query, err = query.Merge(ctx.settable())
+		if err != nil {
+			return err
+		}
+
 		task := Task{
 			WritePending: true,
 			Status:       STATUS_PENDING,
@@ -75,15 +100,29 @@ func CommandAdd(conf Config, ctx, query Query) error {
 			Due:          query.Due,
 			Notes:        query.Note,
 		}
-		task = ts.MustLoadTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Added %s", task)
+		if err := ts.ApplyDependencies(&task, query); err != nil {
+			return err
+		}
+
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.SavePendingChanges(); err != nil {
+			return err
+		}
+
+		if err := conf.Commit(CMD_ADD, &task, "Added %s", task); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// CommandContext sets a global context for dstask.
+// CommandContext sets a global context for dstask, or saves, uses or lists
+// named contexts.
 func CommandContext(conf Config, state State, ctx, query Query) error {
 	if len(os.Args) < 3 {
 		fmt.Println(ctx)
@@ -91,15 +130,183 @@ func CommandContext(conf Config, state State, ctx, query Query) error {
 		if err := state.SetContext(Query{}); err != nil {
 			return err
 		}
+	} else if os.Args[2] == "list" {
+		return DisplayContexts(conf.Repo, state.ContextName)
+	} else if os.Args[2] == "save" {
+		if len(os.Args) < 4 {
+			return errors.New("context name required, eg. dstask context save work")
+		}
+
+		name := os.Args[3]
+		// the current context, unless another is given, which is saved as
+		// given
+		context := ctx.String()
+		if len(os.Args) > 4 {
+			context = strings.Join(os.Args[4:], " ")
+		}
+
+		if err := SaveContext(conf.Repo, name, context); err != nil {
+			return err
+		}
+
+		if err := conf.Commit(CMD_CONTEXT, nil, "Saved context %s", name); err != nil {
+			return err
+		}
+
+		if err := state.UseContext(conf.Repo, name); err != nil {
+			return err
+		}
+	} else if os.Args[2] == "use" {
+		if len(os.Args) < 4 {
+			return errors.New("context name required, eg. dstask context use work")
+		}
+
+		if err := state.UseContext(conf.Repo, os.Args[3]); err != nil {
+			return err
+		}
 	} else {
 		if err := state.SetContext(query); err != nil {
 			return err
 		}
 	}
 
-	state.Save(conf.StateFile)
+	return state.Save(conf.StateFile)
+}
 
-	return nil
+// CommandDefer hides tasks until the given date, when they become pending
+// again.
+func CommandDefer(conf Config, ctx, query Query) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) == 0 {
+		return errors.New("no ID(s) specified")
+	}
+
+	if query.Text == "" {
+		return errors.New("date required, eg. dstask 15 defer next-monday")
+	}
+
+	wait, err := ParseStrToDate(query.Text)
+	if err != nil {
+		return err
+	}
+
+	if !wait.After(time.Now()) {
+		return errors.New("tasks can only be deferred to a future date")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	tx := ts.Begin(CMD_DEFER)
+	defer tx.Rollback()
+
+	for _, id := range query.IDs {
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		task.Status = STATUS_DEFERRED
+		task.Wait = wait
+
+		if err := tx.Update(task, "Deferred %s until %s", task, wait.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(conf, "Deferred %d tasks until "+wait.Format("2006-01-02"))
+}
+
+// CommandDelegate marks tasks as delegated to someone else.
+func CommandDelegate(conf Config, ctx, query Query) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) == 0 {
+		return errors.New("no ID(s) specified")
+	}
+
+	if query.Text == "" {
+		return errors.New("delegate required, eg. dstask 15 delegate alice")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	tx := ts.Begin(CMD_DELEGATE)
+	defer tx.Rollback()
+
+	for _, id := range query.IDs {
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		task.Status = STATUS_DELEGATED
+		task.DelegatedTo = query.Text
+
+		if err := tx.Update(task, "Delegated %s to %s", task, task.DelegatedTo); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(conf, "Delegated %d tasks")
+}
+
+// CommandUndelegate marks delegated tasks as pending again, for when the work
+// comes back.
+func CommandUndelegate(conf Config, ctx, query Query) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) == 0 {
+		return errors.New("no ID(s) specified")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	tx := ts.Begin(CMD_UNDELEGATE)
+	defer tx.Rollback()
+
+	for _, id := range query.IDs {
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if task.Status != STATUS_DELEGATED {
+			return fmt.Errorf("task %d is not delegated", id)
+		}
+
+		task.Status = STATUS_PENDING
+		task.DelegatedTo = ""
+
+		if query.Text != "" {
+			if task.Notes != "" {
+				task.Notes += "\n"
+			}
+
+			task.Notes += query.Text
+		}
+
+		if err := tx.Update(task, "Undelegated %s", task); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(conf, "Undelegated %d tasks")
 }
 
 // CommandDone marks a task as done.
@@ -112,15 +319,22 @@ func CommandDone(conf Config, ctx, query Query) error {
 		return errors.New("no ID(s) specified")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
+	tx := ts.Begin(CMD_DONE)
+	defer tx.Rollback()
+
 	// iterate over IDs instead of filtering; it's clearer and enables us to
 	// test each ID exists, and ignore context/operators
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		task.Status = STATUS_RESOLVED
 		task.Resolved = time.Now()
 
@@ -128,12 +342,12 @@ func CommandDone(conf Config, ctx, query Query) error {
 			task.Notes += "\n" + query.Text
 		}
 
-		ts.MustUpdateTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Resolved %s", task)
+		if err := tx.Update(task, "Resolved %s", task); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return tx.Commit(conf, "Resolved %d tasks")
 }
 
 // CommandEdit edits a task's metadata, such as status, projects, tags, etc.
@@ -146,13 +360,20 @@ func CommandEdit(conf Config, ctx, query Query) error {
 		return errors.New("no ID(s) specified")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
+	tx := ts.Begin(CMD_EDIT)
+	defer tx.Rollback()
+
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		data, err := yaml.Marshal(&task)
 		if err != nil {
 			// TODO present error to user, specific error message is important
@@ -160,24 +381,27 @@ func CommandEdit(conf Config, ctx, query Query) error {
 		}
 
 		for {
-			edited := MustEditBytes(data, MakeTempFilename(task.ID, task.Summary, "yml"))
+			edited, err := EditBytes(conf.Editor, data, MakeTempFilename(task.ID, task.Summary, "yml"))
+			if err != nil {
+				return err
+			}
 
 			err = yaml.Unmarshal(edited, &task)
 			if err == nil {
 				break
-			} else {
-				// edit is a special case that won't be used as part of an API,
-				// so it's OK to exit
-				ConfirmOrAbort("Failed to unmarshal %s\nTry again?", err)
+			}
+
+			if err := ConfirmOrAbort("Failed to unmarshal %s\nTry again?", err); err != nil {
+				return err
 			}
 		}
 
-		ts.MustUpdateTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Edited %s", task)
+		if err := tx.Update(task, "Edited %s", task); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return tx.Commit(conf, "Edited %d tasks")
 }
 
 // CommandHelp prints for a specific command or all commands.
@@ -196,13 +420,17 @@ func CommandLog(conf Config, ctx, query Query) error {
 		return errors.New("task description required")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
 	ctx.PrintContextDescription()
-	query = query.Merge(ctx)
+	query, err = query.Merge(ctx.settable())
+	if err != nil {
+		return err
+	}
+
 	task := Task{
 		WritePending: true,
 		Status:       STATUS_RESOLVED,
@@ -213,9 +441,18 @@ func CommandLog(conf Config, ctx, query Query) error {
 		Due:          query.Due,
 		Resolved:     time.Now(),
 	}
-	task = ts.MustLoadTask(task)
-	ts.SavePendingChanges()
-	MustGitCommit(conf.Repo, "Logged %s", task)
+	task, err = ts.LoadTask(task)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.SavePendingChanges(); err != nil {
+		return err
+	}
+
+	if err := conf.Commit(CMD_LOG, &task, "Logged %s", task); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -227,44 +464,65 @@ func CommandModify(conf Config, ctx, query Query) error {
 		return errors.New("no operations specified")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	if err := query.checkSettable(); err != nil {
+		return err
+	}
+
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
+	tx := ts.Begin(CMD_MODIFY)
+	defer tx.Rollback()
+
 	if len(query.IDs) == 0 {
 		ts.Filter(ctx)
 
 		if StdoutIsTTY() {
-			ConfirmOrAbort(
+			if err := ConfirmOrAbort(
 				"no IDs specified. Apply to all %d tasks in current ctx?",
 				len(ts.Tasks()),
-			)
+			); err != nil {
+				return err
+			}
 		}
 
 		for _, task := range ts.Tasks() {
 			task.Modify(query)
-			ts.MustUpdateTask(task)
-			ts.SavePendingChanges()
-			MustGitCommit(conf.Repo, "Modified %s", task)
+			if err := ts.ApplyDependencies(&task, query); err != nil {
+				return err
+			}
+
+			if err := tx.Update(task, "Modified %s", task); err != nil {
+				return err
+			}
 		}
 	} else {
 		for _, id := range query.IDs {
-			task := ts.MustGetByID(id)
+			task, err := ts.GetByID(id)
+			if err != nil {
+				return err
+			}
+
 			task.Modify(query)
-			ts.MustUpdateTask(task)
-			ts.SavePendingChanges()
-			MustGitCommit(conf.Repo, "Modified %s", task)
+			if err := ts.ApplyDependencies(&task, query); err != nil {
+				return err
+			}
+
+			if err := tx.Update(task, "Modified %s", task); err != nil {
+				return err
+			}
 		}
 	}
 
-	return nil
+	return tx.Commit(conf, "Modified %d tasks")
 }
 
 // CommandNext prints the unresolved tasks associated with the current context.
 // This is the default command.
 func CommandNext(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
@@ -276,14 +534,21 @@ func CommandNext(conf Config, ctx, query Query) error {
 		}
 	} else {
 		// apply context
-		query = query.Merge(ctx)
+		query, err = query.Merge(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
 	ts.Filter(query)
-	if err := ts.DisplayByNext(ctx, true); err != nil {
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
 		return err
 	}
 
+	if outputFormat() == FORMAT_TABLE {
+		ts.PrintProjectDeadlines(time.Now())
+	}
+
 	return nil
 }
 
@@ -297,22 +562,33 @@ func CommandNote(conf Config, ctx, query Query) error {
 		return errors.New("operators not valid in this context")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
+	tx := ts.Begin(CMD_NOTE)
+	defer tx.Rollback()
+
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		// If stdout is a TTY, we may open the editor
 		if StdoutIsTTY() {
 			if query.Text == "" {
-				task.Notes = string(
-					MustEditBytes(
-						[]byte(task.Notes),
-						MakeTempFilename(task.ID, task.Summary, "md"),
-					),
+				notes, err := EditBytes(
+					conf.Editor,
+					[]byte(task.Notes),
+					MakeTempFilename(task.ID, task.Summary, "md"),
 				)
+				if err != nil {
+					return err
+				}
+
+				task.Notes = string(notes)
 			} else {
 				if task.Notes == "" {
 					task.Notes = query.Text
@@ -321,18 +597,18 @@ func CommandNote(conf Config, ctx, query Query) error {
 				}
 			}
 
-			ts.MustUpdateTask(task)
-			ts.SavePendingChanges()
-			MustGitCommit(conf.Repo, "Edit note %s", task)
+			if err := tx.Update(task, "Edit note %s", task); err != nil {
+				return err
+			}
 		} else {
 			// If stdout is not a TTY, we simply write markdown notes to stdout
 			if err := WriteStdout([]byte(task.Notes)); err != nil {
-				ExitFail("Could not write to stdout: %v", err)
+				return fmt.Errorf("could not write to stdout: %w", err)
 			}
 		}
 	}
 
-	return nil
+	return tx.Commit(conf, "Edit notes of %d tasks")
 }
 
 // CommandOpen opens a task URL in the browser, if the task has a URL.
@@ -345,13 +621,17 @@ func CommandOpen(conf Config, ctx, query Query) error {
 		return errors.New("operators not valid in this context")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		urls := xurls.Relaxed().FindAllString(task.Summary+" "+task.Notes, -1)
 
 		if len(urls) == 0 {
@@ -359,7 +639,9 @@ func CommandOpen(conf Config, ctx, query Query) error {
 		}
 
 		for _, url := range urls {
-			MustOpenBrowser(url)
+			if err := OpenBrowser(url); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -376,89 +658,302 @@ func CommandRemove(conf Config, ctx, query Query) error {
 		return errors.New("operators not valid in this context")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		fmt.Println(task)
 	}
 
 	if StdoutIsTTY() {
-		ConfirmOrAbort(
+		if err := ConfirmOrAbort(
 			"\nThe above %d task(s) will be deleted without checking subtasks. Continue?",
 			len(query.IDs),
-		)
+		); err != nil {
+			return err
+		}
 	}
 
+	tx := ts.Begin(CMD_REMOVE)
+	defer tx.Rollback()
+
+	// commit comment, put in body
+	tx.Comment = query.Text
+
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		// Mark our task for deletion
 		task.Deleted = true
 
-		// MustUpdateTask validates and normalises our task object
-		ts.MustUpdateTask(task)
-		ts.SavePendingChanges()
-
-		if query.Text != "" {
-			// commit comment, put in body
-			MustGitCommit(conf.Repo, "Removed: %s\n\n%s", task, query.Text)
-		} else {
-			MustGitCommit(conf.Repo, "Removed: %s", task)
+		// Update validates and normalises our task object
+		if err := tx.Update(task, "Removed: %s", task); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return tx.Commit(conf, "Removed: %d tasks")
 }
 
 // CommandShowActive prints a list of active tasks.
 func CommandShowActive(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	query, err = query.Merge(ctx)
 	if err != nil {
 		return err
 	}
 
-	query = query.Merge(ctx)
 	ts.Filter(query)
 	ts.FilterByStatus(STATUS_ACTIVE)
-	if err := ts.DisplayByNext(ctx, true); err != nil {
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// CommandShowProjects prints a list of projects associated with all tasks.
-// Ignores context/query for valid output.
-func CommandShowProjects(conf Config, ctx, query Query) error {
-	if len(query.IDs) > 0 || query.HasOperators() {
-		return errors.New("query/context not supported for show-projects")
+// CommandReport runs the named report of the config, with an optional
+// filter, or lists the reports if no name is given.
+func CommandReport(conf Config, ctx Query) error {
+	if len(os.Args) < 3 {
+		return DisplayReports(conf.Reports)
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, true)
+	name := os.Args[2]
+
+	report, ok := conf.Reports[name]
+	if !ok {
+		return fmt.Errorf("no report named %s, see dstask report", name)
+	}
+
+	query, err := ParseQuery(os.Args[3:]...)
 	if err != nil {
 		return err
 	}
 
-	if err := ts.DisplayProjects(); err != nil {
+	if len(query.IDs) > 0 {
+		return errors.New("IDs not valid for reports")
+	}
+
+	// validated when the config was loaded
+	filter, _ := ParseContext(report.Filter)
+
+	query, err = query.Merge(filter)
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	// a sort order of the command line or report wins over that of the context
+	sortKeys := query.Sort
+	if len(sortKeys) == 0 {
+		// validated when the config was loaded
+		sortKeys, _ = ParseSortKeys(report.Sort)
+	}
 
-// CommandShowOpen prints a list of open tasks without truncation.
-func CommandShowOpen(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	query, err = query.Merge(ctx)
 	if err != nil {
 		return err
 	}
 
-	query = query.Merge(ctx)
-	ts.Filter(query)
-	if err := ts.DisplayByNext(ctx, false); err != nil {
+	ts, err := conf.LoadTaskSet(slices.Contains(report.Statuses, STATUS_RESOLVED))
+	if err != nil {
+		return err
+	}
+
+	if len(report.Statuses) > 0 {
+		ts.UnHide()
+		ts.FilterByStatus(report.Statuses...)
+	}
+
+	ts.Filter(query)
+	ts.UpdateUrgency(conf.Urgency, time.Now())
+
+	if len(sortKeys) > 0 {
+		ts.SortBy(sortKeys)
+	} else {
+		ts.SortByNext()
+		ts.sortByQuery()
+	}
+
+	columns := report.Columns
+	if columns == nil {
+		columns = conf.Columns
+	}
+
+	return ts.DisplayReport(ctx, columns)
+}
+
+// CommandProject shows or updates the record of a project, eg.
+//
+//	dstask project infra owner:alice deadline:2026-12-01 Migrate to k8s
+func CommandProject(conf Config) error {
+	if len(os.Args) < 3 {
+		return errors.New("project name required, eg. dstask project infra")
+	}
+
+	// projects of tasks are lowercase, see Task.Normalise
+	name := strings.ToLower(strings.TrimPrefix(os.Args[2], "project:"))
+
+	record, err := LoadProjectRecord(conf.Repo, name)
+	if err != nil {
+		return err
+	}
+
+	if len(os.Args) == 3 {
+		return DisplayProjectRecord(name, record)
+	}
+
+	var description []string
+
+	for _, arg := range os.Args[3:] {
+		if owner, ok := strings.CutPrefix(arg, "owner:"); ok {
+			record.Owner = owner
+		} else if deadline, ok := strings.CutPrefix(arg, "deadline:"); ok {
+			if deadline == "none" || deadline == "" {
+				record.Deadline = time.Time{}
+
+				continue
+			}
+
+			_, record.Deadline, err = ParseDueDateArg("due:" + deadline)
+			if err != nil {
+				return err
+			}
+		} else if arg == "archive" {
+			record.Archived = true
+		} else if arg == "unarchive" {
+			record.Archived = false
+		} else {
+			description = append(description, arg)
+		}
+	}
+
+	if len(description) > 0 {
+		record.Description = strings.Join(description, " ")
+	}
+
+	if err := SaveProjectRecord(conf.Repo, name, record); err != nil {
+		return err
+	}
+
+	if err := conf.Commit(CMD_PROJECT, nil, "Updated project %s", name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandShowProjects prints a list of projects associated with all tasks.
+// Ignores context/query for valid output.
+func CommandShowProjects(conf Config, ctx, query Query) error {
+	if len(query.IDs) > 0 || query.HasOperators() || len(query.Sort) > 0 {
+		return errors.New("query/context not supported for show-projects")
+	}
+
+	ts, err := conf.LoadTaskSet(true)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.DisplayProjects(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandShowDelegated prints a list of delegated tasks, grouped by delegate.
+func CommandShowDelegated(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
+	ts.Filter(query)
+	ts.FilterByStatus(STATUS_DELEGATED)
+	ts.UpdateUrgency(conf.Urgency, time.Now())
+
+	return ts.DisplayByDelegate()
+}
+
+// CommandShowDeferred prints a list of deferred tasks.
+func CommandShowDeferred(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	ts.UnHide()
+	ts.FilterByStatus(STATUS_DEFERRED)
+
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
+	ts.Filter(query)
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandShowOverdue prints a list of unresolved tasks that are past their due
+// date.
+func CommandShowOverdue(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
+	ts.Filter(query)
+	ts.Filter(Query{DateFilter: "overdue"})
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandShowOpen prints a list of open tasks without truncation.
+func CommandShowOpen(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
+	ts.Filter(query)
+	if err := ts.DisplayByNext(conf, ctx, false); err != nil {
 		return err
 	}
 
@@ -467,15 +962,19 @@ func CommandShowOpen(conf Config, ctx, query Query) error {
 
 // CommandShowPaused prints a list of paused tasks.
 func CommandShowPaused(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	query, err = query.Merge(ctx)
 	if err != nil {
 		return err
 	}
 
-	query = query.Merge(ctx)
 	ts.Filter(query)
 	ts.FilterByStatus(STATUS_PAUSED)
-	if err := ts.DisplayByNext(ctx, true); err != nil {
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
 		return err
 	}
 
@@ -484,41 +983,48 @@ func CommandShowPaused(conf Config, ctx, query Query) error {
 
 // CommandShowResolved prints a list of resolved tasks.
 func CommandShowResolved(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, true)
+	ts, err := conf.LoadTaskSet(true)
 	if err != nil {
 		return err
 	}
 
-	query = query.Merge(ctx)
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
 
 	ts.UnHide()
 	ts.Filter(query)
 	ts.FilterByStatus(STATUS_RESOLVED)
-	ts.DisplayByWeek()
+	ts.UpdateUrgency(conf.Urgency, time.Now())
 
-	return nil
+	return ts.DisplayByWeek()
 }
 
 // CommandShowTags prints a list of all tags associated with non-resolved tasks.
 func CommandShowTags(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
-	query = query.Merge(ctx)
-	ts.Filter(query)
+	if len(query.Sort) > 0 {
+		return errors.New("sort order not supported for show-tags")
+	}
 
-	for tag := range ts.GetTags() {
-		fmt.Println(tag)
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	ts.Filter(query)
+
+	return ts.DisplayTags()
 }
 
 // CommandShowTemplates show a list of task templates.
 func CommandShowTemplates(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
@@ -526,9 +1032,36 @@ func CommandShowTemplates(conf Config, ctx, query Query) error {
 	ts.UnHide()
 	ts.FilterByStatus(STATUS_TEMPLATE)
 
-	query = query.Merge(ctx)
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
 	ts.Filter(query)
-	if err := ts.DisplayByNext(ctx, true); err != nil {
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandShowRecurring shows a list of recurring tasks.
+func CommandShowRecurring(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	ts.UnHide()
+	ts.FilterByStatus(STATUS_RECURRING)
+
+	query, err = query.Merge(ctx)
+	if err != nil {
+		return err
+	}
+
+	ts.Filter(query)
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
 		return err
 	}
 
@@ -542,13 +1075,15 @@ func CommandShowUnorganised(conf Config, ctx, query Query) error {
 		return errors.New("query/context not used for show-unorganised")
 	}
 
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
+	// no filter, but may have a sort order
+	ts.Filter(Query{Sort: query.Sort})
 	ts.FilterOrganised()
-	if err := ts.DisplayByNext(ctx, true); err != nil {
+	if err := ts.DisplayByNext(conf, ctx, true); err != nil {
 		return err
 	}
 
@@ -558,7 +1093,7 @@ func CommandShowUnorganised(conf Config, ctx, query Query) error {
 // CommandStart marks an existing task as started, by ID. If no ID is
 // specified, it creates a new task and starts it.
 func CommandStart(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
@@ -568,27 +1103,47 @@ func CommandStart(conf Config, ctx, query Query) error {
 	}
 
 	if len(query.IDs) > 0 {
+		tx := ts.Begin(CMD_START)
+		defer tx.Rollback()
+
+		var started []Task
+
 		// start given tasks by IDs
 		for _, id := range query.IDs {
-			task := ts.MustGetByID(id)
+			task, err := ts.GetByID(id)
+			if err != nil {
+				return err
+			}
+
 			task.Status = STATUS_ACTIVE
 
 			if query.Text != "" {
 				task.Notes += "\n" + query.Text
 			}
 
-			ts.MustUpdateTask(task)
+			if err := tx.Update(task, "Started %s", task); err != nil {
+				return err
+			}
+
+			started = append(started, task)
+		}
 
-			ts.SavePendingChanges()
-			MustGitCommit(conf.Repo, "Started %s", task)
+		if err := tx.Commit(conf, "Started %d tasks"); err != nil {
+			return err
+		}
 
+		for _, task := range started {
 			if task.Notes != "" {
-				fmt.Printf("\nNotes on task %d:\n\033[38;5;245m%s\033[0m\n\n", task.ID, task.Notes)
+				fmt.Printf("\nNotes on task %d:\n%s\n\n", task.ID, paint(os.Stdout, FG_FADED, task.Notes))
 			}
 		}
 	} else if query.Text != "" {
 		// create a new task that is already active (started)
-		query = query.Merge(ctx)
+		query, err = query.Merge(ctx.settable())
+		if err != nil {
+			return err
+		}
+
 		task := Task{
 			WritePending: true,
 			Status:       STATUS_ACTIVE,
@@ -598,9 +1153,18 @@ func CommandStart(conf Config, ctx, query Query) error {
 			Priority:     query.Priority,
 			Notes:        query.Note,
 		}
-		task = ts.MustLoadTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Added and started %s", task)
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.SavePendingChanges(); err != nil {
+			return err
+		}
+
+		if err := conf.Commit(CMD_START, &task, "Added and started %s", task); err != nil {
+			return err
+		}
 	} else {
 		return errors.New("nothing to do -- specify an ID or describe a task")
 	}
@@ -610,7 +1174,7 @@ func CommandStart(conf Config, ctx, query Query) error {
 
 // CommandStop marks a task as stopped.
 func CommandStop(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
@@ -623,17 +1187,138 @@ func CommandStop(conf Config, ctx, query Query) error {
 		return errors.New("no ID(s) specified")
 	}
 
+	tx := ts.Begin(CMD_STOP)
+	defer tx.Rollback()
+
 	for _, id := range query.IDs {
-		task := ts.MustGetByID(id)
+		task, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
 		task.Status = STATUS_PAUSED
 
 		if query.Text != "" {
 			task.Notes += "\n" + query.Text
 		}
 
-		ts.MustUpdateTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Stopped %s", task)
+		if err := tx.Update(task, "Stopped %s", task); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(conf, "Stopped %d tasks")
+}
+
+// CommandSubtask adds a subtask to a task.
+func CommandSubtask(conf Config, ctx, query Query) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) != 1 {
+		return errors.New("exactly one ID must be specified")
+	}
+
+	if query.Text == "" {
+		return errors.New("subtask summary required")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	task, err := ts.GetByID(query.IDs[0])
+	if err != nil {
+		return err
+	}
+
+	task.Subtasks = append(task.Subtasks, SubTask{Summary: query.Text})
+
+	if err := ts.UpdateTask(task); err != nil {
+		return err
+	}
+
+	if err := ts.SavePendingChanges(); err != nil {
+		return err
+	}
+
+	if err := conf.Commit(CMD_SUBTASK, &task, "Added subtask %d to %s", len(task.Subtasks), task); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CommandSubtasks lists the subtasks of a task, with the index used to check
+// them off.
+func CommandSubtasks(conf Config, ctx, query Query) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) != 1 {
+		return errors.New("exactly one ID must be specified")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	task, err := ts.GetByID(query.IDs[0])
+	if err != nil {
+		return err
+	}
+
+	return task.DisplaySubtasks()
+}
+
+// CommandCheck marks subtasks of a task as resolved, or unresolved. The first
+// ID is the task; subsequent numbers are subtask indices, starting at 1.
+func CommandCheck(conf Config, ctx, query Query, resolved bool) error {
+	if query.HasOperators() {
+		return errors.New("operators not valid in this context")
+	}
+
+	if len(query.IDs) < 2 {
+		return errors.New("task ID and subtask number(s) required, eg. dstask 15 check 2")
+	}
+
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	task, err := ts.GetByID(query.IDs[0])
+	if err != nil {
+		return err
+	}
+
+	for _, n := range query.IDs[1:] {
+		if n < 1 || n > len(task.Subtasks) {
+			return fmt.Errorf("task %d has no subtask %d", task.ID, n)
+		}
+
+		task.Subtasks[n-1].Resolved = resolved
+	}
+
+	cmd := CMD_CHECK
+	if !resolved {
+		cmd = CMD_UNCHECK
+	}
+
+	if err := ts.UpdateTask(task); err != nil {
+		return err
+	}
+
+	if err := ts.SavePendingChanges(); err != nil {
+		return err
+	}
+
+	if err := conf.Commit(cmd, &task, "Updated subtasks (%s) of %s", task.SubtaskProgress(), task); err != nil {
+		return err
 	}
 
 	return nil
@@ -641,30 +1326,42 @@ func CommandStop(conf Config, ctx, query Query) error {
 
 // CommandSync pushes and pulls task database changes from the remote repository.
 func CommandSync(repoPath string) error {
-	// TODO(dontlaugh) return error
-	Sync(repoPath)
-
-	return nil
+	return Sync(repoPath)
 }
 
 // CommandTemplate creates a new task template.
 func CommandTemplate(conf Config, ctx, query Query) error {
-	ts, err := LoadTaskSet(conf.Repo, conf.IDsFile, false)
+	ts, err := conf.LoadTaskSet(false)
 	if err != nil {
 		return err
 	}
 
 	if len(query.IDs) > 0 {
+		tx := ts.Begin(CMD_TEMPLATE)
+		defer tx.Rollback()
+
 		for _, id := range query.IDs {
-			task := ts.MustGetByID(id)
+			task, err := ts.GetByID(id)
+			if err != nil {
+				return err
+			}
+
 			task.Status = STATUS_TEMPLATE
 
-			ts.MustUpdateTask(task)
-			ts.SavePendingChanges()
-			MustGitCommit(conf.Repo, "Changed %s to Template", task)
+			if err := tx.Update(task, "Changed %s to Template", task); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Commit(conf, "Changed %d tasks to Templates"); err != nil {
+			return err
 		}
 	} else if query.Text != "" {
-		query = query.Merge(ctx)
+		query, err = query.Merge(ctx.settable())
+		if err != nil {
+			return err
+		}
+
 		task := Task{
 			WritePending: true,
 			Status:       STATUS_TEMPLATE,
@@ -675,9 +1372,189 @@ func CommandTemplate(conf Config, ctx, query Query) error {
 			Notes:        query.Note,
 			Due:          query.Due,
 		}
-		task = ts.MustLoadTask(task)
-		ts.SavePendingChanges()
-		MustGitCommit(conf.Repo, "Created Template %s", task)
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.SavePendingChanges(); err != nil {
+			return err
+		}
+
+		if err := conf.Commit(CMD_TEMPLATE, &task, "Created Template %s", task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CommandRecur creates a new recurring task, or converts existing tasks to
+// recurring tasks.
+func CommandRecur(conf Config, ctx, query Query) error {
+	ts, err := conf.LoadTaskSet(false)
+	if err != nil {
+		return err
+	}
+
+	if len(query.IDs) > 0 {
+		tx := ts.Begin(CMD_RECUR)
+		defer tx.Rollback()
+
+		for _, id := range query.IDs {
+			task, err := ts.GetByID(id)
+			if err != nil {
+				return err
+			}
+
+			task.Modify(query)
+
+			if task.Recur == "" {
+				return errors.New("schedule required, eg. recur:weekly")
+			}
+
+			task.Status = STATUS_RECURRING
+
+			if err := tx.Update(task, "Changed %s to recurring", task); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Commit(conf, "Changed %d tasks to recurring"); err != nil {
+			return err
+		}
+	} else if query.Text != "" {
+		if query.Recur == "" {
+			return errors.New("schedule required, eg. recur:weekly")
+		}
+
+		query, err = query.Merge(ctx.settable())
+		if err != nil {
+			return err
+		}
+
+		task := Task{
+			WritePending: true,
+			Status:       STATUS_RECURRING,
+			Summary:      query.Text,
+			Tags:         query.Tags,
+			Project:      query.Project,
+			Priority:     query.Priority,
+			Notes:        query.Note,
+			Recur:        query.Recur,
+		}
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.SavePendingChanges(); err != nil {
+			return err
+		}
+
+		if err := conf.Commit(CMD_RECUR, &task, "Created recurring task %s", task); err != nil {
+			return err
+		}
+	} else {
+		return errors.New("nothing to do -- specify an ID or describe a task")
+	}
+
+	return nil
+}
+
+// CommandFsck checks the repository for problems, and repairs what it can with
+// --fix, in a single commit.
+func CommandFsck(conf Config, args []string) error {
+	var fix bool
+
+	for _, arg := range args {
+		if arg != "--fix" {
+			Help(CMD_FSCK)
+
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+
+		fix = true
+	}
+
+	store, err := conf.OpenStore()
+	if err != nil {
+		return err
+	}
+
+	problems, err := Fsck(store, fix)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+
+		return nil
+	}
+
+	// problems fixed are listed by the commit message
+	var fixed []string
+
+	for _, problem := range problems {
+		if problem.Fixed {
+			fixed = append(fixed, problem.String())
+		} else {
+			fmt.Println(problem)
+		}
+	}
+
+	if len(fixed) > 0 {
+		if err := conf.Commit(CMD_FSCK, nil, "Fixed %d problem(s)\n\n%s", len(fixed), strings.Join(fixed, "\n")); err != nil {
+			return err
+		}
+	}
+
+	if remaining := len(problems) - len(fixed); remaining > 0 {
+		if fix {
+			return fmt.Errorf("%d problem(s) could not be fixed", remaining)
+		}
+
+		return errors.New("problems found, run dstask fsck --fix to repair what can be")
+	}
+
+	return nil
+}
+
+// CommandGenerate materialises pending instances of recurring tasks that are
+// due, in a single commit.
+func CommandGenerate(conf Config, ctx, query Query) error {
+	if len(query.IDs) > 0 || query.HasOperators() || query.Text != "" {
+		return errors.New("generate takes no arguments")
+	}
+
+	// resolved instances are needed for from=completion schedules
+	ts, err := conf.LoadTaskSet(true)
+	if err != nil {
+		return err
+	}
+
+	created, err := ts.GenerateRecurring(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(created) == 0 {
+		fmt.Println("No recurring tasks due")
+
+		return nil
+	}
+
+	for _, task := range created {
+		fmt.Println(task)
+	}
+
+	if err := ts.SavePendingChanges(); err != nil {
+		return err
+	}
+
+	if err := conf.Commit(CMD_GENERATE, nil, "Generated %d recurring task(s)", len(created)); err != nil {
+		return err
 	}
 
 	return nil
@@ -697,17 +1574,16 @@ func CommandUndo(conf Config, args []string, ctx, query Query) error {
 		}
 	}
 
-	MustRunGitCmd(conf.Repo, "revert", "--no-gpg-sign", "--no-edit", "HEAD~"+strconv.Itoa(n)+"..")
-
-	return nil
+	return RunGitCmd(conf.Repo, "revert", "--no-gpg-sign", "--no-edit", "HEAD~"+strconv.Itoa(n)+"..")
 }
 
 // CommandVersion prints version information for the dstask binary.
 func CommandVersion() {
 	fmt.Printf(
-		"Version: %s\nGit commit: %s\nBuild date: %s\n",
+		"Version: %s\nGit commit: %s\nBuild date: %s\nOutput schema: %d\n",
 		VERSION,
 		GIT_COMMIT,
 		BUILD_DATE,
+		SCHEMA_VERSION,
 	)
 }
@@ -30,7 +30,11 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 
 	// args are dstask _completions <user command line>
 	// parse command line as normal to set rules
-	query := dstask.ParseQuery(originalArgs...)
+	query, err := dstask.ParseQuery(originalArgs...)
+	if err != nil {
+		// nothing to suggest until the command line is valid
+		return
+	}
 
 	// No command and OK to specify command (to run or help)
 	// Note that techically we should only specify commands as available
@@ -65,6 +69,7 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 		dstask.CMD_RESOLVE,
 		dstask.CMD_CONTEXT,
 		dstask.CMD_MODIFY,
+		dstask.CMD_DELEGATE,
 		dstask.CMD_SHOW_NEXT,
 		dstask.CMD_SHOW_PROJECTS,
 		dstask.CMD_SHOW_ACTIVE,
@@ -72,8 +77,14 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 		dstask.CMD_SHOW_OPEN,
 		dstask.CMD_SHOW_RESOLVED,
 		dstask.CMD_SHOW_TEMPLATES,
+		dstask.CMD_SHOW_RECURRING,
+		dstask.CMD_SHOW_DEFERRED,
+		dstask.CMD_SHOW_DELEGATED,
+		dstask.CMD_SHOW_OVERDUE,
+		dstask.CMD_RECUR,
+		dstask.CMD_REPORT,
 	}, query.Cmd) {
-		ts, err := dstask.LoadTaskSet(conf.Repo, conf.IDsFile, false)
+		ts, err := conf.LoadTaskSet(false)
 		if err != nil {
 			log.Printf("completions error: %v\n", err)
 
@@ -97,6 +108,13 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 			}
 		}
 
+		// schedules
+		if query.Cmd == dstask.CMD_RECUR {
+			completions = append(completions, "recur:daily")
+			completions = append(completions, "recur:weekly")
+			completions = append(completions, "recur:monthly")
+		}
+
 		// priorities
 		completions = append(completions, dstask.PRIORITY_CRITICAL)
 		completions = append(completions, dstask.PRIORITY_HIGH)
@@ -105,6 +123,10 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 
 		// projects
 		for _, project := range ts.GetProjects() {
+			if project.Archived {
+				continue
+			}
+
 			completions = append(completions, "project:"+project.Name)
 			completions = append(completions, "-project:"+project.Name)
 		}
@@ -114,6 +136,51 @@ func Completions(conf dstask.Config, args []string, ctx dstask.Query) {
 			completions = append(completions, "+"+tag)
 			completions = append(completions, "-"+tag)
 		}
+
+		// dependencies
+		completions = append(completions, ":blocked")
+		completions = append(completions, ":unblocked")
+
+		// delegates
+		for delegate := range ts.GetDelegates() {
+			if query.Cmd == dstask.CMD_DELEGATE {
+				completions = append(completions, delegate)
+			}
+
+			completions = append(completions, "delegated:"+delegate)
+		}
+	}
+
+	// named contexts
+	if query.Cmd == dstask.CMD_CONTEXT {
+		completions = append(completions, "none", "list", "save", "use")
+
+		contexts, err := dstask.LoadContexts(conf.Repo)
+		if err != nil {
+			log.Printf("completions error: %v\n", err)
+
+			return
+		}
+
+		for name := range contexts {
+			completions = append(completions, name)
+		}
+	}
+
+	// named reports
+	if query.Cmd == dstask.CMD_REPORT {
+		for name := range conf.Reports {
+			completions = append(completions, name)
+		}
+	}
+
+	// sort orders of task lists
+	if query.Cmd == "" || query.Cmd == dstask.CMD_NEXT || query.Cmd == dstask.CMD_REPORT ||
+		(strings.HasPrefix(query.Cmd, "show-") &&
+			query.Cmd != dstask.CMD_SHOW_PROJECTS && query.Cmd != dstask.CMD_SHOW_TAGS) {
+		for _, field := range dstask.SORT_FIELDS {
+			completions = append(completions, "sort:"+field, "sort:-"+field)
+		}
 	}
 
 	if len(originalArgs) > 0 {
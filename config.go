@@ -1,40 +1,316 @@
 package dstask
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
 )
 
 // Config models the dstask application's required configuration. All paths
 // are absolute.
+//
+// Settings are read from the config file, then overridden by environment
+// variables.
 type Config struct {
 	// Path to the git repository
 	Repo string
+	// How tasks are stored, see STORES and OpenStore
+	Store string
 	// Path to the dstask local state file. State will differ between machines
 	StateFile string
 	// Path to the ids file
 	IDsFile string
+	// Path to the database of the bolt store. Like the state, it's local to
+	// the machine, see STORE_BOLT
+	BoltFile string
 	// An unparsed context string, provided via DSTASK_CONTEXT
 	CtxFromEnvVar string
+	// Path to the config file, provided via DSTASK_CONFIG. It does not have
+	// to exist.
+	ConfigFile string
+	// An unparsed context string, used until a context is set on this machine
+	DefaultContext string
+	// Command to edit tasks and notes with
+	Editor string
+	// Sync after every command that commits
+	AutoSync bool
+	// Columns of the task list, see DEFAULT_COLUMNS
+	Columns []string
+	// text/template of commit messages, by command or "default". See
+	// CommitMessage.
+	CommitMessages map[string]string
+	// Coefficients of the urgency score
+	Urgency Urgency
+	// Named reports, by name
+	Reports map[string]Report
+	// text/template of listings, by name, for --template. See
+	// ParseOutputTemplate.
+	Templates map[string]string
+	// name of the theme, see THEMES
+	Theme string
+	// auto, always or never. See colourEnabled.
+	Color string
+	// palette of the theme, overriding the theme by lower case name, eg.
+	// fg_default. See Config.ApplyDisplaySettings.
+	Colours       map[string]int
+	TableMaxWidth int
+	MinTasksShown int
+}
+
+// configFile is the structure of the TOML config file. Settings not given
+// keep their defaults.
+type configFile struct {
+	Repo           string            `toml:"repo"`
+	Store          string            `toml:"store"`
+	Context        string            `toml:"context"`
+	Editor         string            `toml:"editor"`
+	AutoSync       bool              `toml:"auto_sync"`
+	Columns        []string          `toml:"columns"`
+	CommitMessages map[string]string `toml:"commit_messages"`
+	Urgency        *Urgency          `toml:"urgency"`
+	Reports        map[string]Report `toml:"reports"`
+	Templates      map[string]string `toml:"templates"`
+	Theme          string            `toml:"theme"`
+	Color          string            `toml:"color"`
+	Colours        map[string]int    `toml:"colours"`
+	TableMaxWidth  int               `toml:"table_max_width"`
+	MinTasksShown  int               `toml:"min_tasks_shown"`
 }
 
-// NewConfig generates a new Config struct from the environment.
-func NewConfig() Config {
+// NewConfig generates a new Config struct from the config file and the
+// environment.
+func NewConfig() (Config, error) {
 	var conf Config
 
-	conf.CtxFromEnvVar = getEnv("DSTASK_CONTEXT", "")
 	// Determine home directory in a platform-independent way
 	home, err := os.UserHomeDir()
 	if err != nil {
 		// Fallback: use $HOME if present
 		home = os.Getenv("HOME")
 	}
-	defaultRepo := filepath.Join(home, ".dstask")
-	conf.Repo = getEnv("DSTASK_GIT_REPO", defaultRepo)
+
+	conf.Repo = filepath.Join(home, ".dstask")
+	conf.Store = STORE_GIT
+	conf.Columns = DEFAULT_COLUMNS
+	conf.Urgency = DefaultUrgency()
+	conf.Theme = DEFAULT_THEME
+	conf.TableMaxWidth = TABLE_MAX_WIDTH
+	conf.MinTasksShown = MIN_TASKS_SHOWN
+	conf.ConfigFile = getEnv("DSTASK_CONFIG", filepath.Join(home, ".dstaskrc"))
+
+	if err := conf.loadConfigFile(); err != nil {
+		return conf, err
+	}
+
+	conf.CtxFromEnvVar = getEnv("DSTASK_CONTEXT", "")
+	conf.Repo = getEnv("DSTASK_GIT_REPO", conf.Repo)
+	conf.Editor = getEnv("DSTASK_EDITOR", conf.Editor)
+
+	if conf.Editor == "" {
+		conf.Editor = getEnv("EDITOR", "vim")
+	}
+
+	if autoSync := os.Getenv("DSTASK_AUTO_SYNC"); autoSync != "" {
+		conf.AutoSync, err = strconv.ParseBool(autoSync)
+		if err != nil {
+			return conf, fmt.Errorf("invalid DSTASK_AUTO_SYNC: %s", autoSync)
+		}
+	}
+
+	if strings.HasPrefix(conf.Repo, "~/") {
+		conf.Repo = filepath.Join(home, conf.Repo[2:])
+	}
+
 	conf.StateFile = filepath.Join(conf.Repo, ".git", "dstask", "state.bin")
 	conf.IDsFile = filepath.Join(conf.Repo, ".git", "dstask", "ids.bin")
+	conf.BoltFile = filepath.Join(conf.Repo, ".git", "dstask", BOLT_FILENAME)
 
-	return conf
+	return conf, nil
+}
+
+// loadConfigFile overrides the defaults with the settings of the config file,
+// if it exists.
+func (conf *Config) loadConfigFile() error {
+	data, err := os.ReadFile(conf.ConfigFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("couldn't read config file %q: %w", conf.ConfigFile, err)
+	}
+
+	file := configFile{
+		Urgency: &conf.Urgency,
+	}
+
+	md, err := toml.Decode(string(data), &file)
+	if err != nil {
+		return fmt.Errorf("invalid config file %q: %w", conf.ConfigFile, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+
+		return fmt.Errorf("invalid config file %q: unknown settings %s", conf.ConfigFile, strings.Join(keys, ", "))
+	}
+
+	if err := file.validate(); err != nil {
+		return fmt.Errorf("invalid config file %q: %w", conf.ConfigFile, err)
+	}
+
+	if file.Repo != "" {
+		conf.Repo = file.Repo
+	}
+
+	if file.Store != "" {
+		conf.Store = file.Store
+	}
+
+	if file.Columns != nil {
+		conf.Columns = file.Columns
+	}
+
+	if file.Theme != "" {
+		conf.Theme = file.Theme
+	}
+
+	if file.TableMaxWidth > 0 {
+		conf.TableMaxWidth = file.TableMaxWidth
+	}
+
+	if file.MinTasksShown > 0 {
+		conf.MinTasksShown = file.MinTasksShown
+	}
+
+	conf.DefaultContext = file.Context
+	conf.Editor = file.Editor
+	conf.AutoSync = file.AutoSync
+	conf.CommitMessages = file.CommitMessages
+	conf.Color = file.Color
+	conf.Colours = file.Colours
+	conf.Reports = file.Reports
+	conf.Templates = file.Templates
+
+	return nil
+}
+
+func (file configFile) validate() error {
+	if file.Columns != nil {
+		if err := validateColumns(file.Columns); err != nil {
+			return err
+		}
+	}
+
+	if file.Store != "" && !slices.Contains(STORES, file.Store) {
+		return fmt.Errorf("unknown store: %s\nExpected git or bolt", file.Store)
+	}
+
+	if file.Context != "" {
+		context, err := ParseContext(file.Context)
+		if err != nil {
+			return fmt.Errorf("invalid context: %w", err)
+		}
+
+		if err := validateContext(context); err != nil {
+			return fmt.Errorf("invalid context: %w", err)
+		}
+	}
+
+	for name, report := range file.Reports {
+		if !contextNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid report name: %s\nNames are lower case letters, digits, - and _", name)
+		}
+
+		if err := report.validate(); err != nil {
+			return fmt.Errorf("invalid report %s: %w", name, err)
+		}
+	}
+
+	for name, text := range file.Templates {
+		if !contextNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid template name: %s\nNames are lower case letters, digits, - and _", name)
+		}
+
+		if _, err := template.New(name).Funcs(TEMPLATE_FUNCS).Parse(text); err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+	}
+
+	theme := THEMES[DEFAULT_THEME]
+
+	if file.Theme != "" {
+		var ok bool
+		if theme, ok = THEMES[file.Theme]; !ok {
+			return fmt.Errorf("unknown theme: %s", file.Theme)
+		}
+	}
+
+	if file.Color != "" && !slices.Contains(COLOR_MODES, file.Color) {
+		return fmt.Errorf("invalid color: %s\nExpected always, never or auto", file.Color)
+	}
+
+	for name, colour := range file.Colours {
+		if _, ok := themeColours[name]; !ok {
+			return fmt.Errorf("unknown colour: %s", name)
+		}
+
+		if colour < 0 || colour >= theme.Palette {
+			return fmt.Errorf("colour %s is not in the %d-colour palette of the theme: %d", name, theme.Palette, colour)
+		}
+	}
+
+	for cmd, text := range file.CommitMessages {
+		if cmd != "default" && !slices.Contains(ALL_CMDS, cmd) {
+			return fmt.Errorf("unknown command of commit message: %s", cmd)
+		}
+
+		if _, err := template.New(cmd).Parse(text); err != nil {
+			return fmt.Errorf("invalid commit message template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyDisplaySettings replaces the display settings of const.go with the
+// settings of the config. NewConfig doesn't, so that library users keep their
+// own; the dstask command calls it once the config is loaded.
+func (conf Config) ApplyDisplaySettings() {
+	TABLE_MAX_WIDTH = conf.TableMaxWidth
+	MIN_TASKS_SHOWN = conf.MinTasksShown
+
+	// the --color option wins over the config file
+	if COLOR == "" {
+		COLOR = conf.Color
+	}
+
+	// NO_COLOR leaves only the bold, reverse video etc. of monochrome
+	if noColorSet() {
+		THEMES[THEME_MONOCHROME].apply()
+
+		return
+	}
+
+	THEMES[conf.Theme].apply()
+
+	// normal priority tasks follow the default colour, unless set
+	if _, ok := conf.Colours["fg_priority_normal"]; !ok {
+		if colour, ok := conf.Colours["fg_default"]; ok {
+			FG_PRIORITY_NORMAL = colour
+		}
+	}
+
+	for name, colour := range conf.Colours {
+		*themeColours[name] = colour
+	}
 }
 
 // getEnv returns an env var's value, or a default.
@@ -0,0 +1,157 @@
+package dstask
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, data string) Config {
+	t.Helper()
+
+	conf := Config{
+		ConfigFile:    filepath.Join(t.TempDir(), "dstaskrc"),
+		Columns:       DEFAULT_COLUMNS,
+		Urgency:       DefaultUrgency(),
+		Theme:         DEFAULT_THEME,
+		TableMaxWidth: TABLE_MAX_WIDTH,
+		MinTasksShown: MIN_TASKS_SHOWN,
+	}
+
+	assert.NoError(t, os.WriteFile(conf.ConfigFile, []byte(data), 0o600))
+
+	return conf
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	conf := writeConfigFile(t, `
+repo = "~/notes/tasks"
+store = "bolt"
+context = "+work"
+editor = "nvim -u NONE"
+auto_sync = true
+columns = ["id", "urgency", "summary"]
+table_max_width = 120
+
+[colours]
+fg_default = 15
+
+[commit_messages]
+default = "dstask: {{.Message}}"
+add = "Add {{.Task.Summary}}"
+`)
+
+	assert.NoError(t, conf.loadConfigFile())
+	assert.Equal(t, "~/notes/tasks", conf.Repo)
+	assert.Equal(t, STORE_BOLT, conf.Store)
+	assert.Equal(t, "+work", conf.DefaultContext)
+	assert.Equal(t, "nvim -u NONE", conf.Editor)
+	assert.True(t, conf.AutoSync)
+	assert.Equal(t, []string{"id", "urgency", "summary"}, conf.Columns)
+	assert.Equal(t, 120, conf.TableMaxWidth)
+	assert.Equal(t, MIN_TASKS_SHOWN, conf.MinTasksShown, "defaults are kept")
+	assert.Equal(t, 15, conf.Colours["fg_default"])
+
+	msg, err := conf.renderCommitMessage(CommitMessage{Cmd: CMD_ADD, Message: "Added 1: a", Task: Task{Summary: "a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Add a", msg)
+
+	msg, err = conf.renderCommitMessage(CommitMessage{Cmd: CMD_DONE, Message: "Resolved 1: a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dstask: Resolved 1: a", msg)
+}
+
+func TestLoadConfigFileInvalid(t *testing.T) {
+	for _, data := range []string{
+		`colums = ["id"]`,
+		`columns = ["id", "colour"]`,
+		`columns = []`,
+		`context = "fix the bug"`,
+		"[colours]\nfg_defualt = 15",
+		"[colours]\nfg_default = 256",
+		"[commit_messages]\nfrobnicate = \"{{.Message}}\"",
+		"[commit_messages]\nadd = \"{{.Message\"",
+		`auto_sync = "yes"`,
+		`store = "sqlite"`,
+		"[templates]\nBar = \"{{.ID}}\"",
+		"[templates]\nbar = \"{{.ID\"",
+		"[templates]\nbar = \"{{frobnicate .ID}}\"",
+	} {
+		conf := writeConfigFile(t, data)
+		assert.Error(t, conf.loadConfigFile(), data)
+	}
+}
+
+func TestRenderCommitMessageDefault(t *testing.T) {
+	msg, err := Config{}.renderCommitMessage(CommitMessage{Cmd: CMD_ADD, Message: "Added 1: a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Added 1: a", msg)
+}
+
+func TestLoadConfigFileReports(t *testing.T) {
+	conf := writeConfigFile(t, `
+[reports.stale]
+filter = "+work"
+sort = ["created", "-priority"]
+columns = ["id", "age", "summary"]
+statuses = ["pending", "paused"]
+`)
+
+	assert.NoError(t, conf.loadConfigFile())
+	assert.Equal(t, Report{
+		Filter:   "+work",
+		Sort:     []string{"created", "-priority"},
+		Columns:  []string{"id", "age", "summary"},
+		Statuses: []string{"pending", "paused"},
+	}, conf.Reports["stale"])
+
+	for _, data := range []string{
+		"[reports.Stale]\nfilter = \"+work\"",
+		"[reports.stale]\nsort = [\"colour\"]",
+		"[reports.stale]\ncolumns = [\"colour\"]",
+		"[reports.stale]\nstatuses = [\"done\"]",
+		"[reports.stale]\nfilter = \"1 2\"",
+	} {
+		conf := writeConfigFile(t, data)
+		assert.Error(t, conf.loadConfigFile(), data)
+	}
+}
+
+func TestLoadConfigFileTheme(t *testing.T) {
+	conf := writeConfigFile(t, `
+theme = "16-colour"
+color = "never"
+
+[colours]
+fg_default = 15
+`)
+
+	assert.NoError(t, conf.loadConfigFile())
+	assert.Equal(t, THEME_16, conf.Theme)
+	assert.Equal(t, COLOR_NEVER, conf.Color)
+
+	for data, expected := range map[string]string{
+		`theme = "solarized"`:                                "unknown theme: solarized",
+		`color = "sometimes"`:                                "invalid color: sometimes",
+		"theme = \"16-colour\"\n[colours]\nfg_default = 250": "not in the 16-colour palette",
+	} {
+		conf := writeConfigFile(t, data)
+		err := conf.loadConfigFile()
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestNewConfigKeepsDisplaySettings(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	assert.NoError(t, os.WriteFile(config, []byte("table_max_width = 120\n"), 0o600))
+	t.Setenv("DSTASK_CONFIG", config)
+
+	width := TABLE_MAX_WIDTH
+
+	conf, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 120, conf.TableMaxWidth)
+	assert.Equal(t, width, TABLE_MAX_WIDTH, "applied by ApplyDisplaySettings only")
+}
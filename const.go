@@ -17,6 +17,19 @@ var (
 	BUILD_DATE = "Unknown"
 )
 
+// display settings, which can be changed in the config file. See
+// Config.ApplyDisplaySettings.
+var (
+	// if the terminal is too short, show this many tasks anyway.
+	MIN_TASKS_SHOWN = 8
+
+	TABLE_MAX_WIDTH = 160 // keep it readable
+
+	// auto, always or never. Set by the --color option before the config
+	// file is loaded, which only sets it if empty. See colourEnabled.
+	COLOR = ""
+)
+
 const (
 	STATUS_PENDING   = "pending"
 	STATUS_ACTIVE    = "active"
@@ -32,14 +45,26 @@ const (
 	CMD_RM               = "rm"
 	CMD_REMOVE           = "remove"
 	CMD_TEMPLATE         = "template"
+	CMD_RECUR            = "recur"
+	CMD_DEFER            = "defer"
+	CMD_DELEGATE         = "delegate"
+	CMD_UNDELEGATE       = "undelegate"
+	CMD_GENERATE         = "generate"
+	CMD_FSCK             = "fsck"
 	CMD_LOG              = "log"
 	CMD_START            = "start"
 	CMD_NOTE             = "note"
 	CMD_NOTES            = "notes"
+	CMD_SUBTASK          = "subtask"
+	CMD_SUBTASKS         = "subtasks"
+	CMD_CHECK            = "check"
+	CMD_UNCHECK          = "uncheck"
 	CMD_STOP             = "stop"
 	CMD_DONE             = "done"
 	CMD_RESOLVE          = "resolve"
 	CMD_CONTEXT          = "context"
+	CMD_PROJECT          = "project"
+	CMD_REPORT           = "report"
 	CMD_MODIFY           = "modify"
 	CMD_EDIT             = "edit"
 	CMD_UNDO             = "undo"
@@ -54,6 +79,10 @@ const (
 	CMD_SHOW_OPEN        = "show-open"
 	CMD_SHOW_RESOLVED    = "show-resolved"
 	CMD_SHOW_TEMPLATES   = "show-templates"
+	CMD_SHOW_RECURRING   = "show-recurring"
+	CMD_SHOW_DEFERRED    = "show-deferred"
+	CMD_SHOW_DELEGATED   = "show-delegated"
+	CMD_SHOW_OVERDUE     = "show-overdue"
 	CMD_SHOW_UNORGANISED = "show-unorganised"
 	CMD_COMPLETIONS      = "_completions"
 	CMD_HELP             = "help"
@@ -75,34 +104,16 @@ const (
 	MAX_TASKS_OPEN    = 10000
 	TASK_FILENAME_LEN = 40
 
-	// if the terminal is too short, show this many tasks anyway.
-	MIN_TASKS_SHOWN = 8
-
 	// reserve this many lines for status messages/prompt.
 	TERMINAL_HEIGHT_MARGIN = 9
 
+	TABLE_COL_GAP = 2 // differentiate columns
+
 	IGNORE_CONTEXT_KEYWORD = "--"
 	NOTE_MODE_KEYWORD      = "/"
 
-	// theme loosely based on https://github.com/GothenburgBitFactory/taskwarrior/blob/2.6.0/doc/rc/dark-256.theme
-	TABLE_MAX_WIDTH      = 160 // keep it readable
-	TABLE_COL_GAP        = 2   // differentiate columns
-	MODE_HEADER          = 4
-	FG_DEFAULT           = 250
-	BG_DEFAULT_1         = 233
-	BG_DEFAULT_2         = 232
-	MODE_DEFAULT         = 0
-	FG_ACTIVE            = 233
-	BG_ACTIVE            = 250
-	BG_PAUSED            = 236 // task that has been started then stopped
-	FG_PRIORITY_CRITICAL = 160
-	FG_PRIORITY_HIGH     = 166
-	FG_PRIORITY_NORMAL   = FG_DEFAULT
-	FG_PRIORITY_LOW      = 245
-	FG_ACTIVE_PRIORITY_CRITICAL = 124
-	FG_ACTIVE_PRIORITY_HIGH     = 130
-	FG_ACTIVE_PRIORITY_LOW      = 238
-	FG_NOTE              = 240
+	// next warns of project deadlines this close
+	PROJECT_DEADLINE_WARNING_DAYS = 7
 )
 
 // for import (etc) it's necessary to have full context.
@@ -120,6 +131,7 @@ var ALL_STATUSES = []string{
 // statuses which are hidden by default (direct addressing or show- commands
 // needed to see them).
 var HIDDEN_STATUSES = []string{
+	STATUS_DEFERRED,
 	STATUS_RECURRING,
 	STATUS_RESOLVED,
 	STATUS_TEMPLATE,
@@ -134,6 +146,17 @@ var VALID_STATUS_TRANSITIONS = [][]string{
 	{STATUS_PAUSED, STATUS_RESOLVED},
 	{STATUS_ACTIVE, STATUS_RESOLVED},
 	{STATUS_PENDING, STATUS_TEMPLATE},
+	{STATUS_PENDING, STATUS_RECURRING},
+	{STATUS_PENDING, STATUS_DEFERRED},
+	{STATUS_PAUSED, STATUS_DEFERRED},
+	{STATUS_DEFERRED, STATUS_PENDING},
+	{STATUS_DEFERRED, STATUS_ACTIVE},
+	{STATUS_DEFERRED, STATUS_RESOLVED},
+	{STATUS_PENDING, STATUS_DELEGATED},
+	{STATUS_ACTIVE, STATUS_DELEGATED},
+	{STATUS_PAUSED, STATUS_DELEGATED},
+	{STATUS_DELEGATED, STATUS_PENDING},
+	{STATUS_DELEGATED, STATUS_RESOLVED},
 }
 
 // for most operations, it's not necessary or desirable to load the expensive resolved tasks.
@@ -147,23 +170,52 @@ var NON_RESOLVED_STATUSES = []string{
 	STATUS_TEMPLATE,
 }
 
+// commands where words are content for a task rather than a search, so a
+// /path/ is not a regular expression
+var CONTENT_CMDS = []string{
+	CMD_ADD,
+	CMD_TEMPLATE,
+	CMD_RECUR,
+	CMD_LOG,
+	CMD_START,
+	CMD_STOP,
+	CMD_DONE,
+	CMD_RESOLVE,
+	CMD_NOTE,
+	CMD_NOTES,
+	CMD_SUBTASK,
+	CMD_UNDELEGATE,
+}
+
 var ALL_CMDS = []string{
 	CMD_NEXT,
 	CMD_ADD,
 	CMD_RM,
 	CMD_REMOVE,
 	CMD_TEMPLATE,
+	CMD_RECUR,
+	CMD_GENERATE,
+	CMD_DEFER,
+	CMD_DELEGATE,
+	CMD_UNDELEGATE,
 	CMD_LOG,
 	CMD_START,
 	CMD_NOTE,
 	CMD_NOTES,
+	CMD_SUBTASK,
+	CMD_SUBTASKS,
+	CMD_CHECK,
+	CMD_UNCHECK,
 	CMD_STOP,
 	CMD_DONE,
 	CMD_RESOLVE,
 	CMD_CONTEXT,
+	CMD_PROJECT,
+	CMD_REPORT,
 	CMD_MODIFY,
 	CMD_EDIT,
 	CMD_UNDO,
+	CMD_FSCK,
 	CMD_SYNC,
 	CMD_OPEN,
 	CMD_GIT,
@@ -175,6 +227,10 @@ var ALL_CMDS = []string{
 	CMD_SHOW_OPEN,
 	CMD_SHOW_RESOLVED,
 	CMD_SHOW_TEMPLATES,
+	CMD_SHOW_RECURRING,
+	CMD_SHOW_DEFERRED,
+	CMD_SHOW_DELEGATED,
+	CMD_SHOW_OVERDUE,
 	CMD_SHOW_UNORGANISED,
 	CMD_COMPLETIONS,
 	CMD_PRINT_BASH_COMPLETION,
@@ -0,0 +1,76 @@
+package dstask
+
+// named contexts are stored in the repository, so they are synchronised
+// between machines. Which one is active is local state.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const CONTEXTS_FILENAME = "contexts.yml"
+
+var contextNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LoadContexts reads the named contexts of the repository, as a map of name
+// to context string.
+func LoadContexts(repoPath string) (map[string]string, error) {
+	contexts := make(map[string]string)
+
+	data, err := os.ReadFile(filepath.Join(repoPath, CONTEXTS_FILENAME))
+	if os.IsNotExist(err) {
+		return contexts, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &contexts); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", CONTEXTS_FILENAME, err)
+	}
+
+	return contexts, nil
+}
+
+// SaveContext stores a named context string in the repository, replacing
+// any context of the same name. The string is stored as given, and parsed
+// each time the context is used, so relative dates such as due.within:7d stay
+// relative. The change is not committed.
+func SaveContext(repoPath, name, context string) error {
+	if !contextNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid context name: %s\nNames are lower case letters, digits, - and _", name)
+	}
+
+	query, err := ParseContext(context)
+	if err != nil {
+		return err
+	}
+
+	if err := validateContext(query); err != nil {
+		return err
+	}
+
+	contexts, err := LoadContexts(repoPath)
+	if err != nil {
+		return err
+	}
+
+	contexts[name] = strings.Join(strings.Fields(context), " ")
+
+	data, err := yaml.Marshal(contexts)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(repoPath, CONTEXTS_FILENAME), data, 0o600)
+}
+
+// ParseContext parses a context string, as given by DSTASK_CONTEXT or stored
+// for a named context.
+func ParseContext(context string) (Query, error) {
+	return ParseQuery(strings.Fields(context)...)
+}
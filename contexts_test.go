@@ -0,0 +1,52 @@
+package dstask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedContexts(t *testing.T) {
+	repo := t.TempDir()
+
+	contexts, err := LoadContexts(repo)
+	assert.NoError(t, err)
+	assert.Empty(t, contexts)
+
+	work := mustParseQuery(t, "+work", "(project:a", "or", "project:b)")
+	assert.NoError(t, SaveContext(repo, "work", "+work (project:a or project:b)"))
+	assert.NoError(t, SaveContext(repo, "home", "+home"))
+	assert.NoError(t, SaveContext(repo, "soon", "due.within:7d"))
+
+	assert.Error(t, SaveContext(repo, "Bad Name", "+work"))
+	assert.Error(t, SaveContext(repo, "text", "some text"))
+	assert.Error(t, SaveContext(repo, "invalid", "due:someday"))
+
+	contexts, err = LoadContexts(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"work": "+work (project:a or project:b)",
+		"home": "+home",
+		"soon": "due.within:7d",
+	}, contexts)
+
+	var state State
+	assert.NoError(t, state.SetContext(mustParseQuery(t, "-bug")))
+	assert.Error(t, state.UseContext(repo, "missing"))
+	assert.NoError(t, state.UseContext(repo, "work"))
+	assert.Equal(t, Query{}, state.Context)
+
+	ctx, err := state.ActiveContext(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, work.String(), ctx.String())
+
+	// setting an anonymous context leaves the named context
+	assert.NoError(t, state.SetContext(mustParseQuery(t, "-bug")))
+	ctx, err = state.ActiveContext(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "-bug", ctx.String())
+
+	state.ContextName = "deleted"
+	_, err = state.ActiveContext(repo)
+	assert.Error(t, err)
+}
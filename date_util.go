@@ -1,6 +1,9 @@
 package dstask
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -9,27 +12,47 @@ func startOfDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
-func weekDayStrToTime(dateStr string, selector string) (due time.Time) {
-	weekdays := map[string]time.Weekday{
-		"sun":       time.Sunday,
-		"sunday":    time.Sunday,
-		"mon":       time.Monday,
-		"monday":    time.Monday,
-		"tue":       time.Tuesday,
-		"tues":      time.Tuesday,
-		"tuesday":   time.Tuesday,
-		"wed":       time.Wednesday,
-		"wednesday": time.Wednesday,
-		"thu":       time.Thursday,
-		"thur":      time.Thursday,
-		"thurs":     time.Thursday,
-		"thursday":  time.Thursday,
-		"fri":       time.Friday,
-		"friday":    time.Friday,
-		"sat":       time.Saturday,
-		"saturday":  time.Saturday,
-	}
-	nowWeekday := time.Now().Weekday()
+func isSameDay(a, b time.Time) bool {
+	return startOfDay(a).Equal(startOfDay(b))
+}
+
+// isPastDue returns true if a due date has passed. A due date without a time
+// of day is the whole day, so it passes at the end of the day.
+func isPastDue(due, now time.Time) bool {
+	if hasTimeOfDay(due) {
+		return due.Before(now)
+	}
+
+	return due.Before(startOfDay(now))
+}
+
+// hasTimeOfDay returns true if the time is not midnight.
+func hasTimeOfDay(t time.Time) bool {
+	return !t.Equal(startOfDay(t))
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun":       time.Sunday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"monday":    time.Monday,
+	"tue":       time.Tuesday,
+	"tues":      time.Tuesday,
+	"tuesday":   time.Tuesday,
+	"wed":       time.Wednesday,
+	"wednesday": time.Wednesday,
+	"thu":       time.Thursday,
+	"thur":      time.Thursday,
+	"thurs":     time.Thursday,
+	"thursday":  time.Thursday,
+	"fri":       time.Friday,
+	"friday":    time.Friday,
+	"sat":       time.Saturday,
+	"saturday":  time.Saturday,
+}
+
+func weekDayStrToTime(now time.Time, dateStr string, selector string) (due time.Time) {
+	nowWeekday := now.Weekday()
 	targetWeekday, ok := weekdays[strings.ToLower(dateStr)]
 	if !ok {
 		return time.Time{}
@@ -37,58 +60,229 @@ func weekDayStrToTime(dateStr string, selector string) (due time.Time) {
 	daysDifference := int(targetWeekday) - int(nowWeekday)
 
 	if selector == "next" {
-		return startOfDay(time.Now().AddDate(0, 0, daysDifference+7))
+		return startOfDay(now.AddDate(0, 0, daysDifference+7))
 	}
 	if selector == "this" || selector == "" {
 		if daysDifference < 0 {
-			return startOfDay(time.Now().AddDate(0, 0, daysDifference+7))
+			return startOfDay(now.AddDate(0, 0, daysDifference+7))
+		}
+	}
+	return startOfDay(now.AddDate(0, 0, daysDifference))
+}
+
+// parseOrdinal parses 1st, 2nd, 3rd, 4th etc.
+func parseOrdinal(str string) (int, bool) {
+	if len(str) < 3 {
+		return 0, false
+	}
+
+	switch str[len(str)-2:] {
+	case "st", "nd", "rd", "th":
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(str[:len(str)-2])
+
+	return n, err == nil && n > 0
+}
+
+// ordinalWeekDayStrToTime parses eg. 2nd-tuesday: the nth weekday of this
+// month, or of next month if that day has passed. Months without a 5th
+// weekday are skipped.
+func ordinalWeekDayStrToTime(now time.Time, n int, dateStr string) (due time.Time) {
+	if n > 5 {
+		return time.Time{}
+	}
+
+	targetWeekday, ok := weekdays[dateStr]
+	if !ok {
+		return time.Time{}
+	}
+
+	today := startOfDay(now)
+
+	for month := 0; month < 3; month++ {
+		first := time.Date(today.Year(), today.Month()+time.Month(month), 1, 0, 0, 0, 0, time.Local)
+		offset := (int(targetWeekday) - int(first.Weekday()) + 7) % 7
+		day := first.AddDate(0, 0, offset+7*(n-1))
+
+		if day.Month() == first.Month() && !day.Before(today) {
+			return day
+		}
+	}
+
+	return time.Time{}
+}
+
+// durationStrToTime parses an offset from today, eg. +3d, +2w, +1m, +1y or
+// -1w.
+func durationStrToTime(now time.Time, dateStr string) (due time.Time, err error) {
+	if len(dateStr) < 3 || (dateStr[0] != '+' && dateStr[0] != '-') {
+		return time.Time{}, nil
+	}
+
+	n, err := strconv.Atoi(dateStr[1 : len(dateStr)-1])
+	if err != nil {
+		return time.Time{}, nil
+	}
+
+	if dateStr[0] == '-' {
+		n = -n
+	}
+
+	today := startOfDay(now)
+
+	switch dateStr[len(dateStr)-1] {
+	case 'd':
+		return today.AddDate(0, 0, n), nil
+	case 'w':
+		return today.AddDate(0, 0, 7*n), nil
+	case 'm':
+		return today.AddDate(0, n, 0), nil
+	case 'y':
+		return today.AddDate(n, 0, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid duration unit in %s, expected d, w, m or y", dateStr)
+}
+
+// periodStrToTime parses the start or end of the current week (Monday to
+// Sunday), month, quarter or year, eg. eom or soq.
+func periodStrToTime(now time.Time, dateStr string) (due time.Time) {
+	today := startOfDay(now)
+	year, month, _ := today.Date()
+
+	quarterStart := time.Month((int(month)-1)/3*3 + 1)
+	daysIntoWeek := (int(today.Weekday()) + 6) % 7
+
+	switch dateStr {
+	case "sow":
+		return today.AddDate(0, 0, -daysIntoWeek)
+	case "eow":
+		return today.AddDate(0, 0, 6-daysIntoWeek)
+	case "som":
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	case "eom":
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local)
+	case "soq":
+		return time.Date(year, quarterStart, 1, 0, 0, 0, 0, time.Local)
+	case "eoq":
+		return time.Date(year, quarterStart+3, 0, 0, 0, 0, 0, time.Local)
+	case "soy":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	case "eoy":
+		return time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+	}
+
+	return time.Time{}
+}
+
+// parseTimeOfDay parses a time of day such as 9am, 9:30pm or 15:00. A bare
+// hour is only accepted with am/pm, to avoid ambiguity with days of the month.
+func parseTimeOfDay(str string) (hour, minute int, ok bool) {
+	for _, layout := range []string{"3pm", "3:04pm", "15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t.Hour(), t.Minute(), true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// splitTimeOfDay splits an optional time of day suffix from a date, separated
+// by T (ISO 8601) or -. For example 2026-10-20t15:00 or tomorrow-9am.
+func splitTimeOfDay(lower string) (date string, hour, minute int, ok bool) {
+	for _, sep := range []string{"t", "-"} {
+		i := strings.LastIndex(lower, sep)
+		if i <= 0 {
+			continue
+		}
+
+		if hour, minute, ok := parseTimeOfDay(lower[i+1:]); ok {
+			return lower[:i], hour, minute, true
 		}
 	}
-	return startOfDay(time.Now().AddDate(0, 0, daysDifference))
+
+	return lower, 0, 0, false
 }
 
-func ParseStrToDate(dateStr string) (due time.Time) {
-	now := time.Now()
+// ParseStrToDate parses a date, with an optional time of day. Without a time,
+// the date is at midnight.
+func ParseStrToDate(dateStr string) (time.Time, error) {
+	return parseStrToDateAt(time.Now(), dateStr)
+}
+
+func parseStrToDateAt(now time.Time, dateStr string) (time.Time, error) {
 	lower := strings.ToLower(strings.TrimSpace(dateStr))
 
+	date, hour, minute, hasTime := splitTimeOfDay(lower)
+
+	day, err := parseStrToDay(now, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date format: %s\n"+
+			"Expected format: YYYY-MM-DD, MM-DD or DD, relative date like 'next-monday', 'today', '+3d', 'eom', '2nd-tue', etc.\n"+
+			"Optionally followed by a time, eg. 2025-12-24T15:00 or tomorrow-9am", dateStr)
+	}
+
+	if !hasTime {
+		return day, nil
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local), nil
+}
+
+func parseStrToDay(now time.Time, lower string) (time.Time, error) {
 	switch lower {
 	case "today":
-		return startOfDay(now)
+		return startOfDay(now), nil
 	case "tomorrow":
-		return startOfDay(now.AddDate(0, 0, 1))
+		return startOfDay(now.AddDate(0, 0, 1)), nil
 	case "yesterday":
-		return startOfDay(now.AddDate(0, 0, -1))
+		return startOfDay(now.AddDate(0, 0, -1)), nil
 	}
 
-	// Check for next-[weekday], this-[weekday]
+	// Check for +3d, -1w etc
+	if t, err := durationStrToTime(now, lower); err != nil || !t.IsZero() {
+		return t, err
+	}
+
+	// Check for eom, soq etc
+	if t := periodStrToTime(now, lower); !t.IsZero() {
+		return t, nil
+	}
+
+	// Check for next-[weekday], this-[weekday], 2nd-[weekday]
 	parts := strings.SplitN(lower, "-", 2)
 	if len(parts) == 2 {
 		selector, rest := parts[0], parts[1]
-		if wdTime := weekDayStrToTime(rest, selector); !wdTime.IsZero() {
-			return wdTime
+		if n, ok := parseOrdinal(selector); ok {
+			if wdTime := ordinalWeekDayStrToTime(now, n, rest); !wdTime.IsZero() {
+				return wdTime, nil
+			}
+		} else if wdTime := weekDayStrToTime(now, rest, selector); !wdTime.IsZero() {
+			return wdTime, nil
 		}
 	}
 
 	// Check for [weekday]
-	if wdTime := weekDayStrToTime(lower, ""); !wdTime.IsZero() {
-		return wdTime
+	if wdTime := weekDayStrToTime(now, lower, ""); !wdTime.IsZero() {
+		return wdTime, nil
 	}
 
 	// Try YYYY-MM-DD, MM-DD, or DD
-	if t, err := time.ParseInLocation("2006-01-02", dateStr, time.Local); err == nil {
-		return t
+	if t, err := time.ParseInLocation("2006-01-02", lower, time.Local); err == nil {
+		return t, nil
 	}
-	if t, err := time.ParseInLocation("01-02", dateStr, time.Local); err == nil {
-		t = t.AddDate(time.Now().Year(), 0, 0)
-		return t
+	if t, err := time.ParseInLocation("01-02", lower, time.Local); err == nil {
+		t = t.AddDate(now.Year(), 0, 0)
+		return t, nil
 	}
-	if t, err := time.ParseInLocation("2", dateStr, time.Local); err == nil {
-		year, month, _ := time.Now().Date()
+	if t, err := time.ParseInLocation("2", lower, time.Local); err == nil {
+		year, month, _ := now.Date()
 		t = t.AddDate(year, int(month)-1, 0)
-		return t
+		return t, nil
 	}
 
-	ExitFail("Invalid due date format: " + dateStr + "\n" +
-		"Expected format: YYYY-MM-DD, MM-DD or DD, relative date like 'next-monday', 'today', etc.")
-	return time.Time{}
+	return time.Time{}, errors.New("invalid date")
 }
@@ -0,0 +1,194 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStrToDateWithTime(t *testing.T) {
+	today := startOfDay(time.Now())
+	tomorrow := today.AddDate(0, 0, 1)
+
+	type testCase struct {
+		input    string
+		expected time.Time
+	}
+
+	testCases := []testCase{
+		{"2026-10-20T15:00", time.Date(2026, 10, 20, 15, 0, 0, 0, time.Local)},
+		{"2026-10-20t15:30", time.Date(2026, 10, 20, 15, 30, 0, 0, time.Local)},
+		{"2026-10-20", time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)},
+		{"tomorrow-9am", tomorrow.Add(9 * time.Hour)},
+		{"today-9:30pm", today.Add(21*time.Hour + 30*time.Minute)},
+		{"Tomorrow-12pm", tomorrow.Add(12 * time.Hour)},
+		{"today-18:45", today.Add(18*time.Hour + 45*time.Minute)},
+	}
+
+	for _, tc := range testCases {
+		actual, err := ParseStrToDate(tc.input)
+		assert.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, actual, tc.input)
+	}
+}
+
+func TestParseStrToDateRelative(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+
+	// Wednesday
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, time.Local)
+
+	type testCase struct {
+		input    string
+		expected time.Time
+	}
+
+	testCases := []testCase{
+		{"+3d", date(2026, 10, 17)},
+		{"-1d", date(2026, 10, 13)},
+		{"+2w", date(2026, 10, 28)},
+		{"+1m", date(2026, 11, 14)},
+		{"+1y", date(2027, 10, 14)},
+		{"sow", date(2026, 10, 12)},
+		{"eow", date(2026, 10, 18)},
+		{"som", date(2026, 10, 1)},
+		{"eom", date(2026, 10, 31)},
+		{"soq", date(2026, 10, 1)},
+		{"eoq", date(2026, 12, 31)},
+		{"soy", date(2026, 1, 1)},
+		{"eoy", date(2026, 12, 31)},
+		{"2nd-tuesday", date(2026, 11, 10)},
+		{"3rd-wed", date(2026, 10, 21)},
+		{"2nd-wed", date(2026, 10, 14)},
+		{"1st-fri", date(2026, 11, 6)},
+		{"eom-5pm", date(2026, 10, 31).Add(17 * time.Hour)},
+		{"+1d-9am", date(2026, 10, 15).Add(9 * time.Hour)},
+		{"next-monday", date(2026, 10, 19)},
+	}
+
+	for _, tc := range testCases {
+		actual, err := parseStrToDateAt(now, tc.input)
+		assert.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, actual, tc.input)
+	}
+
+	for _, input := range []string{"+3x", "+d", "6th-monday", "2nd-funday", "eox", "invalid"} {
+		_, err := parseStrToDateAt(now, input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseDueDateArg(t *testing.T) {
+	filter, due, err := ParseDueDateArg("due.before:eow")
+	assert.NoError(t, err)
+	assert.Equal(t, "before", filter)
+	assert.False(t, due.IsZero())
+
+	_, _, err = ParseDueDateArg("due.afber:today")
+	assert.Error(t, err)
+
+	_, _, err = ParseDueDateArg("due:+3z")
+	assert.Error(t, err)
+}
+
+func TestParseDueDateToStrWithTime(t *testing.T) {
+	tomorrow := startOfDay(time.Now()).AddDate(0, 0, 1)
+
+	task := Task{Due: tomorrow}
+	assert.Equal(t, "tomorrow", task.ParseDueDateToStr())
+
+	task.Due = tomorrow.Add(15 * time.Hour)
+	assert.Equal(t, "tomorrow 15:00", task.ParseDueDateToStr())
+}
+
+func TestMatchesFilterDueIsDayGranular(t *testing.T) {
+	tomorrow := startOfDay(time.Now()).AddDate(0, 0, 1)
+	task := Task{Due: tomorrow.Add(9 * time.Hour)}
+
+	assert.True(t, task.MatchesFilter(Query{Due: tomorrow}))
+	assert.True(t, task.MatchesFilter(Query{Due: tomorrow, DateFilter: "on"}))
+	assert.False(t, task.MatchesFilter(Query{Due: tomorrow.AddDate(0, 0, 1)}))
+	assert.True(t, task.MatchesFilter(Query{Due: tomorrow, DateFilter: "after"}))
+	assert.True(t, task.MatchesFilter(Query{Due: tomorrow, DateFilter: "before"}))
+	assert.False(t, task.MatchesFilter(Query{Due: tomorrow.AddDate(0, 0, -1), DateFilter: "before"}))
+
+	between := Query{Due: tomorrow.AddDate(0, 0, -1), DateFilter: "after", DueBefore: tomorrow}
+	assert.True(t, task.MatchesFilter(between))
+}
+
+func TestMatchesFilterDueRanges(t *testing.T) {
+	today := startOfDay(time.Now())
+	yesterday := Task{Due: today.AddDate(0, 0, -1)}
+	nextWeek := Task{Due: today.AddDate(0, 0, 6)}
+	nextMonth := Task{Due: today.AddDate(0, 1, 0)}
+
+	overdue := Query{DateFilter: "overdue"}
+	assert.True(t, yesterday.MatchesFilter(overdue))
+	assert.False(t, nextWeek.MatchesFilter(overdue))
+
+	within := Query{Due: today.AddDate(0, 0, 7), DateFilter: "within"}
+	assert.False(t, yesterday.MatchesFilter(within), "overdue tasks are not due within")
+	assert.True(t, nextWeek.MatchesFilter(within))
+	assert.False(t, nextMonth.MatchesFilter(within))
+
+	between := Query{Due: today, DateFilter: "after", DueBefore: today.AddDate(0, 0, 7)}
+	assert.False(t, yesterday.MatchesFilter(between))
+	assert.True(t, nextWeek.MatchesFilter(between))
+	assert.False(t, nextMonth.MatchesFilter(between))
+
+	noDue := Task{}
+	assert.False(t, noDue.MatchesFilter(overdue))
+}
+
+func TestParseDueDateArgWithin(t *testing.T) {
+	today := startOfDay(time.Now())
+
+	filter, due, err := ParseDueDateArg("due.within:7d")
+	assert.NoError(t, err)
+	assert.Equal(t, "within", filter)
+	assert.Equal(t, today.AddDate(0, 0, 7), due)
+
+	filter, due, err = ParseDueDateArg("due.within:+2w")
+	assert.NoError(t, err)
+	assert.Equal(t, "within", filter)
+	assert.Equal(t, today.AddDate(0, 0, 14), due)
+
+	filter, due, err = ParseDueDateArg("due:overdue")
+	assert.NoError(t, err)
+	assert.Equal(t, "overdue", filter)
+	assert.True(t, due.IsZero())
+}
+
+func TestOverdueIsDayGranular(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	today := startOfDay(now)
+
+	// a due date without a time is due all day
+	assert.False(t, (&Task{Due: today}).IsOverdue(now))
+	assert.True(t, (&Task{Due: today.AddDate(0, 0, -1)}).IsOverdue(now))
+
+	// with a time, it's overdue from then
+	assert.True(t, (&Task{Due: today.Add(9 * time.Hour)}).IsOverdue(now))
+	assert.False(t, (&Task{Due: today.Add(17 * time.Hour)}).IsOverdue(now))
+
+	overdue := Query{DateFilter: "overdue"}
+	today = startOfDay(time.Now())
+
+	assert.False(t, (&Task{Due: today}).MatchesFilter(overdue), "due today")
+	assert.True(t, (&Task{Due: today.AddDate(0, 0, -1)}).MatchesFilter(overdue))
+	assert.True(t, (&Task{Due: time.Now().Add(-time.Second)}).MatchesFilter(overdue), "due earlier today")
+	assert.False(t, (&Task{Due: time.Now().Add(time.Minute)}).MatchesFilter(overdue), "due later today")
+}
+
+func TestMatchesFilterWithinIsDayGranular(t *testing.T) {
+	tomorrow := startOfDay(time.Now()).AddDate(0, 0, 1)
+	within := Query{Due: tomorrow, DateFilter: "within"}
+
+	assert.True(t, (&Task{Due: tomorrow}).MatchesFilter(within))
+	assert.True(t, (&Task{Due: tomorrow.Add(15 * time.Hour)}).MatchesFilter(within))
+	assert.False(t, (&Task{Due: tomorrow.AddDate(0, 0, 1)}).MatchesFilter(within))
+	assert.True(t, (&Task{Due: startOfDay(time.Now())}).MatchesFilter(within), "due today")
+}
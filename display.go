@@ -2,93 +2,135 @@ package dstask
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/mattn/go-isatty"
 )
 
-// DisplayByNext renders the TaskSet's array of tasks.
-func (ts *TaskSet) DisplayByNext(ctx Query, truncate bool) error {
-	ts.SortByCreated(Ascending)  // older tasks first (from top) like a FIFO queue
-	ts.SortByPriority(Ascending) // high priority tasks first, of course
+// DisplayByNext renders the TaskSet's array of tasks, most urgent first.
+func (ts *TaskSet) DisplayByNext(conf Config, ctx Query, truncate bool) error {
+	ts.UpdateUrgency(conf.Urgency, time.Now())
+	ts.SortByNext()
+	ts.sortByQuery()
 
-	if StdoutIsTTY() {
-		ctx.PrintContextDescription()
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return ts.writeTasks(format)
+	}
 
-		err := ts.renderTable(truncate)
-		if err != nil {
-			return err
+	ctx.PrintContextDescription()
+
+	err := ts.renderTable(conf.Columns, truncate)
+	if err != nil {
+		return err
+	}
+
+	var critical int
+
+	var totalCritical int
+
+	for _, t := range ts.Tasks() {
+		if t.Priority == PRIORITY_CRITICAL {
+			critical++
 		}
+	}
 
-		var critical int
+	// search outside current filter in taskset
+	for _, t := range ts.AllTasks() {
+		if t.Priority == PRIORITY_CRITICAL && !StrSliceContains(HIDDEN_STATUSES, t.Status) {
+			totalCritical++
+		}
+	}
 
-		var totalCritical int
+	if critical < totalCritical {
+		printPainted(
+			FG_PRIORITY_CRITICAL,
+			"%v critical task(s) outside this context! Use `dstask -- P0` to see them.",
+			totalCritical-critical,
+		)
+	}
 
-		for _, t := range ts.Tasks() {
-			if t.Priority == PRIORITY_CRITICAL {
-				critical++
-			}
+	return nil
+}
+
+// newTaskTable returns a table of the tasks with the given columns, see
+// taskColumns.
+func (ts *TaskSet) newTaskTable(w int, tasks []Task, columns []string) *Table {
+	// progress column only if relevant
+	var showProgress bool
+
+	for _, t := range tasks {
+		if len(t.Subtasks) > 0 {
+			showProgress = true
+
+			break
 		}
+	}
 
-		// search outside current filter in taskset
-		for _, t := range ts.AllTasks() {
-			if t.Priority == PRIORITY_CRITICAL && !StrSliceContains(HIDDEN_STATUSES, t.Status) {
-				totalCritical++
-			}
+	var (
+		header []string
+		values []func(t *Task) string
+	)
+
+	highlightColumn := -1
+
+	for _, name := range columns {
+		if name == COLUMN_PROGRESS && !showProgress {
+			continue
 		}
 
-		if critical < totalCritical {
-			fmt.Printf(
-				"\033[38;5;%dm%v critical task(s) outside this context! Use `dstask -- P0` to see them.\033[0m\n",
-				FG_PRIORITY_CRITICAL,
-				totalCritical-critical,
-			)
+		if name == COLUMN_SUMMARY {
+			highlightColumn = len(header)
 		}
 
-		return nil
+		header = append(header, taskColumns[name].header)
+		values = append(values, taskColumns[name].value)
 	}
-	// stdout is not a tty
-	return ts.renderJSON()
-}
 
-func (ts *TaskSet) renderJSON() error {
-	unfilteredTasks := ts.Tasks()
+	table := NewTable(w, header...)
 
-	data, err := json.MarshalIndent(unfilteredTasks, "", "  ")
-	if err != nil {
-		return err
+	if highlightColumn >= 0 {
+		table.Highlight = highlightRegexp(ts.searches)
+		table.HighlightColumn = highlightColumn
 	}
 
-	_, err = io.Copy(os.Stdout, bytes.NewBuffer(data))
+	for _, t := range tasks {
+		row := make([]string, 0, len(values))
+		for _, value := range values {
+			row = append(row, value(&t))
+		}
+
+		table.AddRow(row, t.Style())
+	}
 
-	return err
+	return table
 }
 
-func (ts *TaskSet) renderTable(truncate bool) error {
+func (ts *TaskSet) renderTable(columns []string, truncate bool) error {
 	tasks := ts.Tasks()
 	total := len(tasks)
 
 	if ts.NumTotal() == 0 {
 		fmt.Println("No tasks found. Run `dstask help` for instructions.")
 	} else if len(tasks) == 0 {
-		ExitFail("No matching tasks in given context or filter.")
+		return errors.New("no matching tasks in given context or filter")
 	} else if len(tasks) == 1 {
 		task := tasks[0]
 		task.Display()
 
 		if task.Notes != "" {
-			fmt.Printf("\nNotes on task %d:\n\033[38;5;245m%s\033[0m\n\n", task.ID, task.Notes)
+			fmt.Printf("\nNotes on task %d:\n%s\n\n", task.ID, paint(os.Stdout, FG_FADED, task.Notes))
 		}
 
 		return nil
 	} else {
-		w, h := MustGetTermSize()
+		w, h := GetTermSize()
 
 		maxTasks := max(h-TERMINAL_HEIGHT_MARGIN, MIN_TASKS_SHOWN)
 
@@ -96,35 +138,7 @@ func (ts *TaskSet) renderTable(truncate bool) error {
 			tasks = tasks[:maxTasks]
 		}
 
-		table := NewTable(
-			w,
-			"ID",
-			"Priority",
-			"Tags",
-			"Due",
-			"Project",
-			"Summary",
-		)
-
-		for _, t := range tasks {
-			style := t.Style()
-
-			table.AddRow(
-				[]string{
-					// id should be at least 2 chars wide to match column header
-					// (headers can be truncated)
-					fmt.Sprintf("%-2d", t.ID),
-					t.Priority,
-					strings.Join(t.Tags, " "),
-					t.ParseDueDateToStr(),
-					t.Project,
-					t.LongSummary(),
-				},
-				style,
-			)
-		}
-
-		table.Render()
+		ts.newTaskTable(w, tasks, columns).Render()
 
 		if truncate && maxTasks < total {
 			fmt.Printf("\n%v/%v tasks shown.\n", maxTasks, total)
@@ -137,7 +151,7 @@ func (ts *TaskSet) renderTable(truncate bool) error {
 }
 
 func (task *Task) Display() {
-	w, _ := MustGetTermSize()
+	w, _ := GetTermSize()
 
 	table := NewTable(
 		w,
@@ -147,6 +161,7 @@ func (task *Task) Display() {
 
 	table.AddRow([]string{"ID", strconv.Itoa(task.ID)}, RowStyle{})
 	table.AddRow([]string{"Priority", task.Priority}, RowStyle{})
+	table.AddRow([]string{"Urgency", fmt.Sprintf("%.1f", task.Urgency)}, RowStyle{})
 	table.AddRow([]string{"Summary", task.Summary}, RowStyle{})
 	table.AddRow([]string{"Status", task.Status}, RowStyle{})
 	table.AddRow([]string{"Project", task.Project}, RowStyle{})
@@ -162,15 +177,77 @@ func (task *Task) Display() {
 		table.AddRow([]string{"Due", task.Due.String()}, RowStyle{})
 	}
 
+	for _, uuid := range task.Dependencies {
+		table.AddRow([]string{"Depends on", uuid}, RowStyle{})
+	}
+
+	if len(task.Subtasks) > 0 {
+		table.AddRow([]string{"Subtasks", task.SubtaskProgress()}, RowStyle{})
+	}
+
+	if task.Blocked {
+		table.AddRow([]string{"Blocked", "yes"}, RowStyle{})
+	}
+
+	if task.DelegatedTo != "" {
+		table.AddRow([]string{"Delegated to", task.DelegatedTo}, RowStyle{})
+	}
+
+	if !task.Wait.IsZero() {
+		table.AddRow([]string{"Wait", task.Wait.String()}, RowStyle{})
+	}
+
+	if task.Recur != "" {
+		table.AddRow([]string{"Recur", task.Recur}, RowStyle{})
+	}
+
 	table.Render()
 }
 
+// DisplaySubtasks renders the subtasks of a task with their index.
+func (task *Task) DisplaySubtasks() error {
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return writeListing(format, task.Subtasks, SUBTASK_FIELDS, subtaskRow)
+	}
+
+	if len(task.Subtasks) == 0 {
+		fmt.Printf("Task %d has no subtasks. Add one with `dstask %d subtask <summary>`.\n", task.ID, task.ID)
+
+		return nil
+	}
+
+	w, _ := GetTermSize()
+	table := NewTable(
+		w,
+		"#",
+		"Done",
+		"Summary",
+	)
+
+	for i, st := range task.Subtasks {
+		style := RowStyle{}
+		done := ""
+
+		if st.Resolved {
+			style.Fg = FG_PRIORITY_LOW
+			style.Mode = MODE_PRIORITY_LOW
+			done = "x"
+		}
+
+		table.AddRow([]string{strconv.Itoa(i + 1), done, st.Summary}, style)
+	}
+
+	table.Render()
+	fmt.Printf("\n%s subtasks done.\n", task.SubtaskProgress())
+
+	return nil
+}
+
 func (t *Task) Style() RowStyle {
 	now := time.Now()
 	style := RowStyle{}
 	active := t.Status == STATUS_ACTIVE
 	paused := t.Status == STATUS_PAUSED
-	resolved := t.Status == STATUS_RESOLVED
 
 	getFg := func(normalColor, activeColor int) int {
 		if active {
@@ -181,20 +258,27 @@ func (t *Task) Style() RowStyle {
 
 	if t.Priority == PRIORITY_CRITICAL {
 		style.Fg = getFg(FG_PRIORITY_CRITICAL, FG_ACTIVE_PRIORITY_CRITICAL)
-	} else if !t.Due.IsZero() && t.Due.Before(now) && !resolved {
-		style.Fg = getFg(FG_PRIORITY_HIGH, FG_ACTIVE_PRIORITY_HIGH)
+		style.Mode = MODE_PRIORITY_CRITICAL
+	} else if t.IsOverdue(now) {
+		style.Fg = getFg(FG_OVERDUE, FG_ACTIVE_OVERDUE)
+		style.Mode = MODE_OVERDUE
 	} else if t.Priority == PRIORITY_HIGH {
 		style.Fg = getFg(FG_PRIORITY_HIGH, FG_ACTIVE_PRIORITY_HIGH)
+		style.Mode = MODE_PRIORITY_HIGH
 	} else if t.Priority == PRIORITY_LOW {
 		style.Fg = getFg(FG_PRIORITY_LOW, FG_ACTIVE_PRIORITY_LOW)
+		style.Mode = MODE_PRIORITY_LOW
 	} else {
 		style.Fg = getFg(FG_DEFAULT, FG_ACTIVE)
 	}
 
+	// the mode of the status, if the theme has one, wins
 	if active {
 		style.Bg = BG_ACTIVE
+		style.Mode = cmp.Or(MODE_ACTIVE, style.Mode)
 	} else if paused {
 		style.Bg = BG_PAUSED
+		style.Mode = cmp.Or(MODE_PAUSED, style.Mode)
 	}
 
 	return style
@@ -207,121 +291,174 @@ func (p *Project) Style() RowStyle {
 	if p.Active {
 		style.Fg = FG_ACTIVE
 		style.Bg = BG_ACTIVE
+		style.Mode = MODE_ACTIVE
 	} else if p.Priority == PRIORITY_CRITICAL {
 		style.Fg = FG_PRIORITY_CRITICAL
+		style.Mode = MODE_PRIORITY_CRITICAL
 	} else if p.Priority == PRIORITY_HIGH {
 		style.Fg = FG_PRIORITY_HIGH
+		style.Mode = MODE_PRIORITY_HIGH
 	} else if p.Priority == PRIORITY_LOW {
 		style.Fg = FG_PRIORITY_LOW
+		style.Mode = MODE_PRIORITY_LOW
 	}
 
 	return style
 }
 
-func (ts TaskSet) DisplayByWeek() {
+func (ts TaskSet) DisplayByWeek() error {
 	ts.SortByResolved(Ascending)
 
-	if isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()) {
-		w, _ := MustGetTermSize()
-
-		var table *Table
-
-		var lastWeek int
-
-		tasks := ts.Tasks()
-
-		for _, t := range tasks {
-			_, week := t.Resolved.ISOWeek()
-
-			// guaranteed true for first iteration, ISOweek starts with 1.
-			if week != lastWeek {
-				if table != nil && len(table.Rows) > 0 {
-					table.Render()
-				}
-				// insert gap
-				fmt.Printf(
-					"\n\n> Week %d, starting %s\n\n",
-					week,
-					t.Resolved.Format("Mon 2 Jan 2006"),
-				)
-
-				table = NewTable(
-					w,
-					"Resolved",
-					"Priority",
-					"Tags",
-					"Due",
-					"Project",
-					"Summary",
-				)
-			}
+	// grouping by week only makes sense in order of resolution
+	byWeek := !ts.sortByQuery()
 
-			table.AddRow(
-				[]string{
-					t.Resolved.Format("Mon 2"),
-					t.Priority,
-					strings.Join(t.Tags, " "),
-					t.ParseDueDateToStr(),
-					t.Project,
-					t.LongSummary(),
-				},
-				t.Style(),
-			)
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return ts.writeTasks(format)
+	}
 
-			_, lastWeek = t.Resolved.ISOWeek()
-		}
+	w, _ := GetTermSize()
 
-		if table != nil {
-			table.Render()
-		}
+	tasks := ts.Tasks()
 
-		fmt.Printf("%v tasks.\n", len(tasks))
-	} else {
-		// print json
-		if err := ts.renderJSON(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if !byWeek && len(tasks) > 0 {
+		ts.newTaskTable(w, tasks, WEEK_COLUMNS).Render()
+	}
+
+	for i := 0; byWeek && i < len(tasks); {
+		_, week := tasks[i].Resolved.ISOWeek()
+
+		// tasks resolved in the same week
+		j := i + 1
+		for j < len(tasks) {
+			if _, next := tasks[j].Resolved.ISOWeek(); next != week {
+				break
+			}
+
+			j++
 		}
+
+		// insert gap
+		fmt.Printf(
+			"\n\n> Week %d, starting %s\n\n",
+			week,
+			tasks[i].Resolved.Format("Mon 2 Jan 2006"),
+		)
+
+		ts.newTaskTable(w, tasks[i:j], WEEK_COLUMNS).Render()
+
+		i = j
 	}
+
+	fmt.Printf("%v tasks.\n", len(tasks))
+
+	return nil
 }
 
-func (ts TaskSet) DisplayProjects() error {
-	if StdoutIsTTY() {
-		ts.renderProjectsTable()
+// DisplayByDelegate renders tasks in a table per delegate.
+func (ts *TaskSet) DisplayByDelegate() error {
+	ts.SortByCreated(Ascending)
+	ts.SortByPriority(Ascending)
+	ts.sortByQuery()
+	sort.SliceStable(ts.tasks, func(i, j int) bool {
+		return strings.ToLower(ts.tasks[i].DelegatedTo) < strings.ToLower(ts.tasks[j].DelegatedTo)
+	})
+
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return ts.writeTasks(format)
+	}
 
-		return nil
+	w, _ := GetTermSize()
+
+	var table *Table
+
+	var lastDelegate string
+
+	tasks := ts.Tasks()
+
+	for _, t := range tasks {
+		if table == nil || !strings.EqualFold(t.DelegatedTo, lastDelegate) {
+			if table != nil {
+				table.Render()
+			}
+
+			fmt.Printf("\n\n> %s\n\n", t.DelegatedTo)
+
+			table = NewTable(
+				w,
+				"ID",
+				"Priority",
+				"Tags",
+				"Due",
+				"Project",
+				"Summary",
+			)
+		}
+
+		table.AddRow(
+			[]string{
+				fmt.Sprintf("%-2d", t.ID),
+				t.Priority,
+				strings.Join(t.Tags, " "),
+				t.ParseDueDateToStr(),
+				t.Project,
+				t.LongSummary(),
+			},
+			t.Style(),
+		)
+
+		lastDelegate = t.DelegatedTo
 	}
 
-	return ts.renderProjectsJSON()
+	if table != nil {
+		table.Render()
+	}
+
+	fmt.Printf("\n%v tasks.\n", len(tasks))
+
+	return nil
 }
 
-func (ts TaskSet) renderProjectsJSON() error {
-	data, err := json.MarshalIndent(ts.GetProjects(), "", "  ")
-	if err != nil {
-		return err
+func (ts TaskSet) DisplayProjects() error {
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return writeListing(format, ts.GetProjects(), PROJECT_FIELDS, projectRow)
 	}
 
-	_, err = io.Copy(os.Stdout, bytes.NewBuffer(data))
+	ts.renderProjectsTable()
 
-	return err
+	return nil
 }
 
 func (ts TaskSet) renderProjectsTable() {
 	projects := ts.GetProjects()
-	w, _ := MustGetTermSize()
+	w, _ := GetTermSize()
 	table := NewTable(
 		w,
 		"Name",
 		"Progress",
+		"Priority",
+		"Deadline",
+		"Owner",
 		"Created",
+		"Description",
 	)
 
 	for _, project := range projects {
-		if project.TasksResolved < project.Tasks {
+		if project.TasksResolved < project.Tasks && !project.Archived {
+			name := project.Name
+			if project.Parent != "" {
+				// tree, with the name relative to the parent
+				name = strings.Repeat("  ", ProjectDepth(name)) + PROJECT_SEPARATOR + name[len(project.Parent)+1:]
+			}
+
 			table.AddRow(
 				[]string{
-					project.Name,
+					name,
 					fmt.Sprintf("%d/%d", project.TasksResolved, project.Tasks),
+					project.Priority,
+					formatDate(project.Deadline),
+					project.Owner,
 					project.Created.Format("Mon 2 Jan 2006"),
+					project.Description,
 				},
 				project.Style(),
 			)
@@ -330,3 +467,244 @@ func (ts TaskSet) renderProjectsTable() {
 
 	table.Render()
 }
+
+// DisplayProjectRecord renders the record of a project.
+func DisplayProjectRecord(name string, record ProjectRecord) error {
+	// a single record, rather than a listing
+	if outputFormat() != FORMAT_TABLE {
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(os.Stdout, bytes.NewBuffer(data))
+
+		return err
+	}
+
+	w, _ := GetTermSize()
+	table := NewTable(
+		w,
+		"Name",
+		"Value",
+	)
+
+	archived := "no"
+	if record.Archived {
+		archived = "yes"
+	}
+
+	table.AddRow([]string{"Project", name}, RowStyle{})
+	table.AddRow([]string{"Description", record.Description}, RowStyle{})
+	table.AddRow([]string{"Owner", record.Owner}, RowStyle{})
+	table.AddRow([]string{"Deadline", formatDate(record.Deadline)}, RowStyle{})
+	table.AddRow([]string{"Archived", archived}, RowStyle{})
+	table.Render()
+
+	return nil
+}
+
+// PrintProjectDeadlines warns of project deadlines that have passed, or are
+// within PROJECT_DEADLINE_WARNING_DAYS, of the displayed tasks.
+func (ts *TaskSet) PrintProjectDeadlines(now time.Time) {
+	deadlines := make(map[string]time.Time)
+
+	for _, t := range ts.Tasks() {
+		if project, deadline := ts.ProjectDeadline(t.Project); !deadline.IsZero() {
+			deadlines[project] = deadline
+		}
+	}
+
+	projects := make([]string, 0, len(deadlines))
+	for project := range deadlines {
+		projects = append(projects, project)
+	}
+
+	sort.Strings(projects)
+
+	warnAfter := startOfDay(now).AddDate(0, 0, PROJECT_DEADLINE_WARNING_DAYS+1)
+
+	for _, project := range projects {
+		deadline := deadlines[project]
+
+		// a deadline is a day, like a due date
+		if startOfDay(deadline).Before(startOfDay(now)) {
+			printPainted(
+				FG_OVERDUE,
+				"Project %s passed its deadline of %s!",
+				project,
+				formatDate(deadline),
+			)
+		} else if deadline.Before(warnAfter) {
+			printPainted(
+				FG_PRIORITY_HIGH,
+				"Project %s has a deadline of %s.",
+				project,
+				formatDate(deadline),
+			)
+		}
+	}
+}
+
+// DisplayReport renders the tasks of a report in their current order, with
+// the given columns.
+func (ts *TaskSet) DisplayReport(ctx Query, columns []string) error {
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return ts.writeTasks(format)
+	}
+
+	ctx.PrintContextDescription()
+
+	tasks := ts.Tasks()
+	if len(tasks) == 0 {
+		return errors.New("no matching tasks in given context or filter")
+	}
+
+	w, _ := GetTermSize()
+	ts.newTaskTable(w, tasks, columns).Render()
+
+	fmt.Printf("\n%v tasks.\n", len(tasks))
+
+	return nil
+}
+
+// NamedReport is a named report, as listed by "dstask report".
+type NamedReport struct {
+	Name string `json:"name"`
+	Report
+}
+
+// DisplayReports renders the reports of the config.
+func DisplayReports(reports map[string]Report) error {
+	names := make([]string, 0, len(reports))
+	for name := range reports {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	named := make([]NamedReport, 0, len(names))
+	for _, name := range names {
+		named = append(named, NamedReport{Name: name, Report: reports[name]})
+	}
+
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return writeListing(format, named, REPORT_FIELDS, reportRow)
+	}
+
+	if len(named) == 0 {
+		fmt.Println("No reports. Define them in ~/.dstaskrc, see the README.")
+
+		return nil
+	}
+
+	w, _ := GetTermSize()
+	table := NewTable(
+		w,
+		"Name",
+		"Filter",
+		"Sort",
+		"Description",
+	)
+
+	for _, nr := range named {
+		table.AddRow([]string{nr.Name, nr.Filter, strings.Join(nr.Sort, ","), nr.Description}, RowStyle{})
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// NamedContext is a named context, as listed by "context list".
+type NamedContext struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+	Active  bool   `json:"active"`
+}
+
+// DisplayContexts renders the named contexts of the repository, marking the
+// active one.
+func DisplayContexts(repoPath, active string) error {
+	contexts, err := LoadContexts(repoPath)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	named := make([]NamedContext, 0, len(names))
+
+	for _, name := range names {
+		named = append(named, NamedContext{
+			Name:    name,
+			Context: contexts[name],
+			Active:  name == active,
+		})
+	}
+
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return writeListing(format, named, CONTEXT_FIELDS, contextRow)
+	}
+
+	if len(named) == 0 {
+		fmt.Println("No named contexts. Save one with `dstask context save <name> [filter]`.")
+
+		return nil
+	}
+
+	w, _ := GetTermSize()
+	table := NewTable(
+		w,
+		"Name",
+		"Context",
+	)
+
+	for _, nc := range named {
+		style := RowStyle{}
+		if nc.Active {
+			style.Fg = FG_ACTIVE
+			style.Bg = BG_ACTIVE
+			style.Mode = MODE_ACTIVE
+		}
+
+		table.AddRow([]string{nc.Name, nc.Context}, style)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// DisplayTags renders the tags of the tasks, by name.
+func (ts *TaskSet) DisplayTags() error {
+	counts := make(map[string]int)
+
+	for _, t := range ts.Tasks() {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, Tag{Name: name, Tasks: count})
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	if format := outputFormat(); format != FORMAT_TABLE {
+		return writeListing(format, tags, TAG_FIELDS, tagRow)
+	}
+
+	for _, tag := range tags {
+		fmt.Println(tag.Name)
+	}
+
+	return nil
+}
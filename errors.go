@@ -0,0 +1,21 @@
+package dstask
+
+// errors that callers may want to tell apart from others, with errors.Is.
+// Errors returned are wrapped with details, eg. the ID of the task.
+
+import "errors"
+
+var (
+	// ErrTaskNotFound is returned when no task has the given ID or UUID.
+	// Resolved tasks have no ID, and are only found if loaded.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrDuplicateTask is returned when loading a task with the UUID of a
+	// task already loaded.
+	ErrDuplicateTask = errors.New("task already loaded")
+	// ErrInvalidTransition is returned when a task can't go from its status
+	// to another, see VALID_STATUS_TRANSITIONS.
+	ErrInvalidTransition = errors.New("invalid state transition")
+	// ErrAborted is returned when the user doesn't confirm, see
+	// ConfirmOrAbort.
+	ErrAborted = errors.New("aborted")
+)
@@ -0,0 +1,327 @@
+package dstask
+
+// boolean filter expressions, eg:
+//
+//	(+bug or +incident) and not project:infra
+//
+// Terms are any filter operator understood by ParseQuery. Adjacent terms are
+// implicitly combined with and.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	EXPR_AND = "and"
+	EXPR_OR  = "or"
+	EXPR_NOT = "not"
+)
+
+// Expr is a node of a filter expression. Leaves have a Term and no Op.
+type Expr struct {
+	Op   string
+	Args []*Expr
+	Term *Query
+}
+
+// Matches evaluates the expression against a task.
+func (e *Expr) Matches(t *Task) bool {
+	switch e.Op {
+	case EXPR_AND:
+		for _, arg := range e.Args {
+			if !arg.Matches(t) {
+				return false
+			}
+		}
+
+		return true
+	case EXPR_OR:
+		for _, arg := range e.Args {
+			if arg.Matches(t) {
+				return true
+			}
+		}
+
+		return false
+	case EXPR_NOT:
+		return !e.Args[0].Matches(t)
+	}
+
+	return t.MatchesFilter(*e.Term)
+}
+
+// String reconstructs the expression, such that ParseQuery can parse it again.
+func (e *Expr) String() string {
+	switch e.Op {
+	case EXPR_AND, EXPR_OR:
+		parts := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			parts = append(parts, arg.operandString(e.Op))
+		}
+
+		return strings.Join(parts, " "+e.Op+" ")
+	case EXPR_NOT:
+		return EXPR_NOT + " " + e.Args[0].operandString(e.Op)
+	}
+
+	return e.Term.String()
+}
+
+// operandString parenthesises the expression if it binds less tightly than
+// the operator it is an operand of.
+func (e *Expr) operandString(op string) string {
+	if (e.Op == EXPR_OR && op != EXPR_OR) || (e.Op == EXPR_AND && op == EXPR_NOT) {
+		return "(" + e.String() + ")"
+	}
+
+	return e.String()
+}
+
+const (
+	tokenWord = iota
+	tokenTerm
+	tokenOpen
+	tokenClose
+	tokenOperator
+)
+
+// parseFilterExpr extracts a filter expression from the command line,
+// returning the remaining arguments. If there are no parentheses or boolean
+// operators, the arguments are returned unchanged and the expression is nil.
+//
+// and, or and not are only operators next to filter terms, so that they can
+// still be used in task summaries.
+func parseFilterExpr(args []string) ([]string, *Expr, error) {
+	var tokens []string
+
+	var notes []string
+
+	for i, arg := range args {
+		if arg == NOTE_MODE_KEYWORD {
+			notes = args[i:]
+
+			break
+		}
+
+		// a quoted expression, eg. "(+bug or +incident)"
+		if strings.HasPrefix(arg, "(") && strings.ContainsAny(arg, " \t") {
+			for _, field := range strings.Fields(arg) {
+				tokens = append(tokens, splitParens(field)...)
+			}
+		} else {
+			tokens = append(tokens, splitParens(arg)...)
+		}
+	}
+
+	kinds := make([]int, len(tokens))
+
+	var isExpr bool
+
+	for i, token := range tokens {
+		switch {
+		case token == "(":
+			kinds[i] = tokenOpen
+			isExpr = true
+		case token == ")":
+			kinds[i] = tokenClose
+			isExpr = true
+		case isFilterTerm(token):
+			kinds[i] = tokenTerm
+		}
+	}
+
+	// right to left, for not not
+	for i := len(tokens) - 2; i >= 0; i-- {
+		next := kinds[i+1]
+		if strings.ToLower(tokens[i]) == EXPR_NOT &&
+			(next == tokenTerm || next == tokenOpen || next == tokenOperator) {
+			kinds[i] = tokenOperator
+			isExpr = true
+		}
+	}
+
+	for i := 1; i < len(tokens)-1; i++ {
+		lc := strings.ToLower(tokens[i])
+		prev, next := kinds[i-1], kinds[i+1]
+
+		if (lc == EXPR_AND || lc == EXPR_OR) &&
+			(prev == tokenTerm || prev == tokenClose) &&
+			(next == tokenTerm || next == tokenOpen || next == tokenOperator) {
+			kinds[i] = tokenOperator
+			isExpr = true
+		}
+	}
+
+	if !isExpr {
+		return args, nil, nil
+	}
+
+	var rest []string
+
+	p := exprParser{}
+
+	for i, token := range tokens {
+		switch kinds[i] {
+		case tokenWord:
+			rest = append(rest, token)
+		case tokenOperator:
+			p.tokens = append(p.tokens, strings.ToLower(token))
+		default:
+			p.tokens = append(p.tokens, token)
+		}
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, nil, fmt.Errorf("unexpected %s in filter expression", p.tokens[p.pos])
+	}
+
+	return append(rest, notes...), expr, nil
+}
+
+// splitParens splits parentheses from either side of a filter term, eg.
+// (+bug becomes ( +bug
+func splitParens(token string) []string {
+	inner := strings.TrimLeft(token, "(")
+	opening := len(token) - len(inner)
+	core := strings.TrimRight(inner, ")")
+	closing := len(inner) - len(core)
+
+	if opening == 0 && closing == 0 {
+		return []string{token}
+	}
+
+	if core != "" && strings.ToLower(core) != EXPR_NOT && !isFilterTerm(core) {
+		// not an expression, eg. "(maybe)" in a summary
+		return []string{token}
+	}
+
+	var tokens []string
+
+	for range opening {
+		tokens = append(tokens, "(")
+	}
+
+	if core != "" {
+		tokens = append(tokens, core)
+	}
+
+	for range closing {
+		tokens = append(tokens, ")")
+	}
+
+	return tokens
+}
+
+func isFilterTerm(token string) bool {
+	if token == "(" || token == ")" {
+		return false
+	}
+
+	// an invalid operator is still an operator, reported when parsed
+	term, err := parseArgs([]string{token})
+
+	return err != nil || term.HasOperators()
+}
+
+// exprParser is a recursive descent parser of the tokens of a filter
+// expression. or binds less tightly than and, which binds less tightly than
+// not.
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+func (p *exprParser) parseOr() (*Expr, error) {
+	return p.parseBinary(EXPR_OR, p.parseAnd)
+}
+
+func (p *exprParser) parseAnd() (*Expr, error) {
+	return p.parseBinary(EXPR_AND, p.parseUnary)
+}
+
+func (p *exprParser) parseBinary(op string, parseOperand func() (*Expr, error)) (*Expr, error) {
+	var args []*Expr
+
+	for {
+		arg, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		// flatten, eg. +a and (+b and +c)
+		if arg.Op == op {
+			args = append(args, arg.Args...)
+		} else {
+			args = append(args, arg)
+		}
+
+		next := p.peek()
+
+		if next == op {
+			p.pos++
+		} else if op != EXPR_AND || next == "" || next == ")" || next == EXPR_OR {
+			break
+		}
+		// otherwise implicitly and
+	}
+
+	if len(args) == 1 {
+		return args[0], nil
+	}
+
+	return &Expr{Op: op, Args: args}, nil
+}
+
+func (p *exprParser) parseUnary() (*Expr, error) {
+	token := p.peek()
+	p.pos++
+
+	switch token {
+	case "":
+		return nil, errors.New("incomplete filter expression")
+	case EXPR_NOT:
+		arg, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Expr{Op: EXPR_NOT, Args: []*Expr{arg}}, nil
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, errors.New("unbalanced parentheses in filter expression")
+		}
+
+		p.pos++
+
+		return expr, nil
+	case ")", EXPR_AND, EXPR_OR:
+		return nil, fmt.Errorf("unexpected %s in filter expression", token)
+	}
+
+	term, err := parseArgs([]string{token})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expr{Term: &term}, nil
+}
@@ -0,0 +1,105 @@
+package dstask
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilterExpr(t *testing.T) {
+	type testCase struct {
+		input    []string
+		expected string
+		text     string
+	}
+
+	tests := []testCase{
+		{[]string{"next", "(+bug", "or", "+incident)", "and", "not", "project:infra"}, "(+bug or +incident) and not project:infra", ""},
+		{[]string{"next", "(+bug or +incident)", "not", "project:infra"}, "(+bug or +incident) and not project:infra", ""},
+		{[]string{"next", "+a", "or", "+b", "+c"}, "+a or +b and +c", ""},
+		{[]string{"next", "not", "(+a", "+b)"}, "not (+a and +b)", ""},
+		{[]string{"next", "P0", "OR", "priority<=p1"}, "P0 or priority<=P1", ""},
+		{[]string{"next", "fix", "+bug", "or", "+incident"}, "+bug or +incident", "fix"},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.input, " "), func(t *testing.T) {
+			query := mustParseQuery(t, tc.input...)
+			assert.NotNil(t, query.Expr)
+			assert.Equal(t, "next", query.Cmd)
+			assert.Equal(t, tc.text, query.Text)
+			assert.Equal(t, tc.expected, query.Expr.String())
+		})
+	}
+}
+
+func TestParseFilterExprKeywordsInText(t *testing.T) {
+	query := mustParseQuery(t, "add", "buy", "milk", "and", "eggs", "(maybe)", "+shopping")
+
+	assert.Nil(t, query.Expr)
+	assert.Equal(t, "buy milk and eggs (maybe)", query.Text)
+	assert.Equal(t, []string{"shopping"}, query.Tags)
+
+	for _, cmd := range []string{CMD_ADD, CMD_LOG} {
+		query = mustParseQuery(t, cmd, "call", "bob", "(not", "urgent)", "+work")
+		assert.Nil(t, query.Expr, cmd)
+		assert.Equal(t, "call bob (not urgent)", query.Text, cmd)
+		assert.Equal(t, []string{"work"}, query.Tags, cmd)
+
+		query = mustParseQuery(t, cmd, "ask", "about", "+work", "or", "+home")
+		assert.Nil(t, query.Expr, cmd)
+		assert.NoError(t, query.checkSettable(), cmd)
+		assert.Equal(t, []string{"work", "home"}, query.Tags, cmd)
+	}
+}
+
+func TestFilterExprMatches(t *testing.T) {
+	query := mustParseQuery(t, "(+bug", "or", "+incident)", "and", "not", "project:infra")
+
+	bug := Task{Tags: []string{"bug"}, Project: "web"}
+	incident := Task{Tags: []string{"incident"}}
+	infraBug := Task{Tags: []string{"bug"}, Project: "infra"}
+	feature := Task{Tags: []string{"feature"}}
+
+	assert.True(t, bug.MatchesFilter(query))
+	assert.True(t, incident.MatchesFilter(query))
+	assert.False(t, infraBug.MatchesFilter(query))
+	assert.False(t, feature.MatchesFilter(query))
+}
+
+func TestPriorityComparison(t *testing.T) {
+	query := mustParseQuery(t, "priority<=P1")
+	assert.Nil(t, query.Expr)
+	assert.Equal(t, "<=", query.PriorityFilter)
+	assert.Equal(t, PRIORITY_HIGH, query.Priority)
+
+	critical := Task{Priority: PRIORITY_CRITICAL}
+	high := Task{Priority: PRIORITY_HIGH}
+	normal := Task{Priority: PRIORITY_NORMAL}
+
+	assert.True(t, critical.MatchesFilter(query))
+	assert.True(t, high.MatchesFilter(query))
+	assert.False(t, normal.MatchesFilter(query))
+
+	query = mustParseQuery(t, "priority>P1")
+	assert.False(t, high.MatchesFilter(query))
+	assert.True(t, normal.MatchesFilter(query))
+}
+
+func TestFilterExprRoundTrip(t *testing.T) {
+	query := mustParseQuery(t, "+work", "(+bug", "or", "not", "+wontfix)", "priority<P2")
+	again := mustParseQuery(t, strings.Fields(query.String())...)
+
+	assert.Equal(t, query, again)
+
+	// contexts are stored as gob
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(State{Context: query}))
+
+	var state State
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&state))
+	assert.Equal(t, query.String(), state.Context.String())
+}
@@ -0,0 +1,238 @@
+package dstask
+
+// machine-readable output of listings, eg. tasks, projects and tags. See
+// doc/json-schema.md for the fields of each.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	FORMAT_TABLE  = "table"
+	FORMAT_JSON   = "json"
+	FORMAT_NDJSON = "ndjson"
+	FORMAT_CSV    = "csv"
+	FORMAT_TSV    = "tsv"
+	FORMAT_YAML   = "yaml"
+
+	// version of the fields of machine-readable output, incremented when
+	// fields are removed or change meaning. New fields may be added.
+	SCHEMA_VERSION = 1
+)
+
+var FORMATS = []string{FORMAT_TABLE, FORMAT_JSON, FORMAT_NDJSON, FORMAT_CSV, FORMAT_TSV, FORMAT_YAML}
+
+// the output format of listings, set by the --format option. If empty, see
+// outputFormat.
+var FORMAT = ""
+
+// outputFormat returns the format of listings: a table on a terminal, and
+// JSON otherwise, unless --format is given.
+func outputFormat() string {
+	if FORMAT != "" {
+		return FORMAT
+	}
+
+	if StdoutIsTTY() {
+		return FORMAT_TABLE
+	}
+
+	return FORMAT_JSON
+}
+
+// writeListing writes items to stdout in a machine-readable format. row
+// returns the values of the columns of header, for CSV and TSV.
+func writeListing[T any](format string, items []T, header []string, row func(item T) []string) error {
+	var w bytes.Buffer
+
+	// empty, rather than null
+	if items == nil {
+		items = []T{}
+	}
+
+	switch format {
+	case FORMAT_JSON:
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		w.Write(data)
+	case FORMAT_NDJSON:
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+
+			w.Write(data)
+			w.WriteByte('\n')
+		}
+	case FORMAT_YAML:
+		// the fields of the JSON, in order, rather than of the task files
+		data, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+
+		var values []yaml.MapSlice
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return err
+		}
+
+		if data, err = yaml.Marshal(values); err != nil {
+			return err
+		}
+
+		w.Write(data)
+	case FORMAT_CSV:
+		cw := csv.NewWriter(&w)
+		if err := cw.WriteAll(append([][]string{header}, rowsOf(items, row)...)); err != nil {
+			return err
+		}
+	case FORMAT_TSV:
+		for _, values := range append([][]string{header}, rowsOf(items, row)...) {
+			for i, value := range values {
+				values[i] = escapeTSV(value)
+			}
+
+			w.WriteString(strings.Join(values, "\t") + "\n")
+		}
+	case FORMAT_TEMPLATE:
+		if err := writeTemplate(&w, TEMPLATE, items); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("not a machine-readable format: %s", format)
+	}
+
+	return WriteStdout(w.Bytes())
+}
+
+func rowsOf[T any](items []T, row func(item T) []string) [][]string {
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, row(item))
+	}
+
+	return rows
+}
+
+// escapeTSV escapes tabs and line breaks, which TSV can't contain, and so
+// backslashes, in the style of PostgreSQL.
+func escapeTSV(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
+
+// formatTime formats a time for CSV and TSV, as in JSON. Zero times are empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+var TASK_FIELDS = []string{
+	"uuid", "id", "status", "summary", "notes", "tags", "project", "priority",
+	"delegatedTo", "subtasks", "dependencies", "blocked", "urgency", "recur",
+	"parent", "created", "resolved", "due", "wait",
+}
+
+// taskRow returns the fields of a task for CSV and TSV. Lists are separated
+// by spaces, and subtasks are given as their progress, eg. 1/3.
+func taskRow(t Task) []string {
+	return []string{
+		t.UUID,
+		strconv.Itoa(t.ID),
+		t.Status,
+		t.Summary,
+		t.Notes,
+		strings.Join(t.Tags, " "),
+		t.Project,
+		t.Priority,
+		t.DelegatedTo,
+		t.SubtaskProgress(),
+		strings.Join(t.Dependencies, " "),
+		strconv.FormatBool(t.Blocked),
+		strconv.FormatFloat(t.Urgency, 'f', -1, 64),
+		t.Recur,
+		t.Parent,
+		formatTime(t.Created),
+		formatTime(t.Resolved),
+		formatTime(t.Due),
+		formatTime(t.Wait),
+	}
+}
+
+var PROJECT_FIELDS = []string{
+	"name", "parent", "taskCount", "resolvedCount", "active", "priority",
+	"created", "resolved", "description", "owner", "deadline", "archived",
+}
+
+func projectRow(p *Project) []string {
+	return []string{
+		p.Name,
+		p.Parent,
+		strconv.Itoa(p.Tasks),
+		strconv.Itoa(p.TasksResolved),
+		strconv.FormatBool(p.Active),
+		p.Priority,
+		formatTime(p.Created),
+		formatTime(p.Resolved),
+		p.Description,
+		p.Owner,
+		formatTime(p.Deadline),
+		strconv.FormatBool(p.Archived),
+	}
+}
+
+// writeTasks writes the tasks that are not filtered, in their current order.
+func (ts *TaskSet) writeTasks(format string) error {
+	return writeListing(format, ts.Tasks(), TASK_FIELDS, taskRow)
+}
+
+// Tag is a tag of the listed tasks, as listed by show-tags.
+type Tag struct {
+	Name  string `json:"name"`
+	Tasks int    `json:"taskCount"`
+}
+
+var TAG_FIELDS = []string{"name", "taskCount"}
+
+func tagRow(t Tag) []string {
+	return []string{t.Name, strconv.Itoa(t.Tasks)}
+}
+
+var SUBTASK_FIELDS = []string{"summary", "resolved"}
+
+func subtaskRow(st SubTask) []string {
+	return []string{st.Summary, strconv.FormatBool(st.Resolved)}
+}
+
+var CONTEXT_FIELDS = []string{"name", "context", "active"}
+
+func contextRow(nc NamedContext) []string {
+	return []string{nc.Name, nc.Context, strconv.FormatBool(nc.Active)}
+}
+
+var REPORT_FIELDS = []string{"name", "description", "filter", "sort", "columns", "statuses"}
+
+func reportRow(nr NamedReport) []string {
+	return []string{
+		nr.Name,
+		nr.Description,
+		nr.Filter,
+		strings.Join(nr.Sort, " "),
+		strings.Join(nr.Columns, " "),
+		strings.Join(nr.Statuses, " "),
+	}
+}
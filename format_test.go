@@ -0,0 +1,44 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeTSV(t *testing.T) {
+	assert.Equal(t, `a\tb\nc\\d`, escapeTSV("a\tb\nc\\d"))
+	assert.Equal(t, "plain", escapeTSV("plain"))
+}
+
+func TestTaskRow(t *testing.T) {
+	task := Task{
+		UUID:         "3d64c98f-459c-4a5d-b003-30b9727a7e9c",
+		ID:           4,
+		Status:       STATUS_PENDING,
+		Summary:      "a, b",
+		Tags:         []string{"x", "y"},
+		Priority:     PRIORITY_HIGH,
+		Subtasks:     []SubTask{{Summary: "one", Resolved: true}, {Summary: "two"}},
+		Dependencies: []string{"a", "b"},
+		Urgency:      6.5,
+		Created:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	row := taskRow(task)
+	assert.Len(t, row, len(TASK_FIELDS))
+
+	fields := make(map[string]string)
+	for i, name := range TASK_FIELDS {
+		fields[name] = row[i]
+	}
+
+	assert.Equal(t, "4", fields["id"])
+	assert.Equal(t, "x y", fields["tags"])
+	assert.Equal(t, "1/2", fields["subtasks"])
+	assert.Equal(t, "a b", fields["dependencies"])
+	assert.Equal(t, "6.5", fields["urgency"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", fields["created"])
+	assert.Equal(t, "", fields["due"], "zero times are empty")
+}
@@ -0,0 +1,208 @@
+package dstask
+
+// checking the repository for tasks that can't be loaded or are inconsistent,
+// which LoadTaskSet only logs and skips
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FsckProblem is a problem with a stored task, found by Fsck.
+type FsckProblem struct {
+	// where the task is stored
+	Status string
+	// UUID of the task, or the name of the entry if it's not a task
+	UUID    string
+	Problem string
+	// repaired by Fsck
+	Fixed bool
+}
+
+func (p FsckProblem) String() string {
+	s := fmt.Sprintf("%s/%s: %s", p.Status, p.UUID, p.Problem)
+	if p.Fixed {
+		s += " (fixed)"
+	}
+
+	return s
+}
+
+// Fsck checks every task of the store, reporting entries that can't be loaded,
+// tasks stored with several statuses, dependencies on tasks that don't exist,
+// invalid priorities and fields that don't match the status of a task. If fix
+// is set, the problems that can be repaired are, and saved to the store.
+func Fsck(store Store, fix bool) ([]FsckProblem, error) {
+	var problems []FsckProblem
+
+	// copies of each task, by UUID, in the order loaded
+	copies := make(map[string][]Task)
+
+	// every entry listed, loaded or not, so that a dependency on a task that
+	// can't be loaded is not taken to be dangling
+	listed := make(map[string]bool)
+
+	var uuids []string
+
+	for _, status := range ALL_STATUSES {
+		keys, err := store.List(status)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			listed[key] = true
+
+			t, err := store.Load(status, key)
+			if err != nil {
+				problems = append(problems, FsckProblem{
+					Status:  status,
+					UUID:    key,
+					Problem: err.Error(),
+				})
+
+				continue
+			}
+
+			if copies[t.UUID] == nil {
+				uuids = append(uuids, t.UUID)
+			}
+
+			copies[t.UUID] = append(copies[t.UUID], t)
+		}
+	}
+
+	for _, uuid := range uuids {
+		task := copies[uuid][0]
+
+		var fixable []FsckProblem
+
+		if len(copies[uuid]) > 1 {
+			var statuses []string
+
+			for _, other := range copies[uuid][1:] {
+				newer, err := isNewerCopy(store, other, task)
+				if err != nil {
+					return nil, err
+				}
+
+				if newer {
+					task = other
+				}
+			}
+
+			for _, other := range copies[uuid] {
+				statuses = append(statuses, other.Status)
+			}
+
+			// saving the task removes the other copies
+			fixable = append(fixable, FsckProblem{
+				Problem: fmt.Sprintf("stored with several statuses: %s, keeping %s", strings.Join(statuses, ", "), task.Status),
+			})
+		}
+
+		// the task is repaired even if not fixing, so that Validate only
+		// finds the problems that can't be
+		fixable = append(fixable, fsckTask(store, &task, listed)...)
+
+		for i := range fixable {
+			fixable[i].Status = task.Status
+			fixable[i].UUID = task.UUID
+		}
+
+		if fix && len(fixable) > 0 {
+			task.Modified = time.Now()
+
+			if err := store.Save(task); err != nil {
+				return nil, err
+			}
+
+			for i := range fixable {
+				fixable[i].Fixed = true
+			}
+		}
+
+		problems = append(problems, fixable...)
+
+		task.Normalise()
+
+		if err := task.Validate(); err != nil {
+			problems = append(problems, FsckProblem{
+				Status:  task.Status,
+				UUID:    task.UUID,
+				Problem: err.Error(),
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+// fsckTask repairs the fields of a task that are inconsistent, returning the
+// problems repaired. listed are the entries of the store.
+func fsckTask(store Store, task *Task, listed map[string]bool) []FsckProblem {
+	var problems []FsckProblem
+
+	if task.Priority != "" && !IsValidPriority(task.Priority) {
+		priority := strings.ToUpper(task.Priority)
+		if !IsValidPriority(priority) {
+			priority = PRIORITY_NORMAL
+		}
+
+		problems = append(problems, FsckProblem{
+			Problem: fmt.Sprintf("invalid priority %q, setting %s", task.Priority, priority),
+		})
+
+		task.Priority = priority
+	}
+
+	if task.Status == STATUS_RESOLVED && task.Resolved.IsZero() {
+		// the task was most likely resolved when last saved
+		resolved := task.Modified
+
+		if store, ok := store.(ModTimeStore); ok && resolved.IsZero() {
+			if modTime, err := store.ModTime(task.Status, task.UUID); err == nil {
+				resolved = modTime
+			}
+		}
+
+		if resolved.IsZero() {
+			resolved = time.Now()
+		}
+
+		problems = append(problems, FsckProblem{
+			Problem: "resolved, but has no resolved time, setting " + resolved.Format(time.RFC3339),
+		})
+
+		task.Resolved = resolved
+	}
+
+	if task.Status != STATUS_RESOLVED && !task.Resolved.IsZero() {
+		problems = append(problems, FsckProblem{
+			Problem: "not resolved, but has a resolved time, clearing it",
+		})
+
+		task.Resolved = time.Time{}
+	}
+
+	task.Dependencies = slices.DeleteFunc(task.Dependencies, func(uuid string) bool {
+		var problem string
+
+		switch {
+		case uuid == task.UUID:
+			problem = "depends on itself, removing the dependency"
+		case !listed[uuid]:
+			problem = fmt.Sprintf("depends on %s, which doesn't exist, removing the dependency", uuid)
+		default:
+			return false
+		}
+
+		problems = append(problems, FsckProblem{Problem: problem})
+
+		return true
+	})
+
+	return problems
+}
@@ -0,0 +1,135 @@
+package dstask
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFsck(t *testing.T) {
+	store := NewMemoryStore()
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	ok := Task{UUID: "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f01", Status: STATUS_PENDING, Summary: "ok", Created: created}
+	bad := Task{
+		UUID:         "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f02",
+		Status:       STATUS_RESOLVED,
+		Summary:      "bad",
+		Created:      created,
+		Priority:     "p1",
+		Dependencies: []string{ok.UUID, "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f99"},
+	}
+	delegated := Task{UUID: "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f03", Status: STATUS_DELEGATED, Summary: "who?", Created: created}
+
+	for _, task := range []Task{ok, bad, delegated} {
+		assert.NoError(t, store.Save(task))
+	}
+
+	problems, err := Fsck(store, false)
+	assert.NoError(t, err)
+
+	var messages []string
+	for _, p := range problems {
+		assert.False(t, p.Fixed)
+		messages = append(messages, p.String())
+	}
+
+	assert.Len(t, messages, 4)
+	assert.Contains(t, messages,
+		`resolved/3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f02: invalid priority "p1", setting P1`)
+	assert.Contains(t, messages,
+		"resolved/3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f02: depends on 3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f99, "+
+			"which doesn't exist, removing the dependency")
+	assert.Contains(t, messages, "delegated/3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f03: delegated task has no delegate")
+
+	found := slices.ContainsFunc(messages, func(m string) bool {
+		return strings.HasPrefix(m, "resolved/3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f02: resolved, but has no resolved time")
+	})
+	assert.True(t, found, messages)
+
+	stored, err := store.Load(STATUS_RESOLVED, bad.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", stored.Priority, "not fixed")
+
+	problems, err = Fsck(store, true)
+	assert.NoError(t, err)
+	assert.Len(t, problems, 4)
+
+	for _, p := range problems {
+		assert.Equal(t, p.UUID == bad.UUID, p.Fixed, p.String())
+	}
+
+	stored, err = store.Load(STATUS_RESOLVED, bad.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, PRIORITY_HIGH, stored.Priority)
+	assert.False(t, stored.Resolved.IsZero())
+	assert.Equal(t, []string{ok.UUID}, stored.Dependencies)
+
+	problems, err = Fsck(store, true)
+	assert.NoError(t, err)
+	assert.Len(t, problems, 1, "only the delegate is left")
+}
+
+func TestFsckGitStore(t *testing.T) {
+	repoPath := t.TempDir()
+	store := NewGitStore(repoPath)
+
+	hourAgo := time.Now().Add(-time.Hour)
+
+	task := Task{
+		UUID:     "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f04",
+		Status:   STATUS_PENDING,
+		Summary:  "a",
+		Created:  hourAgo,
+		Modified: hourAgo,
+		// can't be loaded, but exists
+		Dependencies: []string{"3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f05"},
+	}
+
+	resolved := task
+	resolved.Status = STATUS_RESOLVED
+	resolved.Resolved = time.Now()
+	resolved.Modified = time.Now()
+
+	// a resolved copy left by an interrupted save, and an older pending one
+	assert.NoError(t, store.Save(resolved))
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoPath, STATUS_PENDING), 0o700))
+	assert.NoError(t, os.WriteFile(store.path(STATUS_PENDING, task.UUID), mustMarshalTask(t, task), 0o600))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, STATUS_PENDING, "notes.txt"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(repoPath, STATUS_PENDING, "3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f05.yml"),
+		[]byte("summary: [unclosed\n"),
+		0o600,
+	))
+
+	problems, err := Fsck(store, true)
+	assert.NoError(t, err)
+	assert.Len(t, problems, 3)
+
+	assert.Contains(t, problems[0].Problem, "failed to unmarshal 3e1f0a6c-8b2d-4c5e-9f7a-0b1c2d3e4f05.yml")
+	assert.False(t, problems[0].Fixed)
+	assert.Equal(t, FsckProblem{
+		Status:  STATUS_PENDING,
+		UUID:    "notes.txt",
+		Problem: "filename does not encode UUID notes.txt",
+	}, problems[1])
+	assert.Equal(t, FsckProblem{
+		Status:  STATUS_RESOLVED,
+		UUID:    task.UUID,
+		Problem: "stored with several statuses: pending, resolved, keeping resolved",
+		Fixed:   true,
+	}, problems[2])
+
+	_, err = store.Load(STATUS_PENDING, task.UUID)
+	assert.ErrorIs(t, err, ErrTaskNotFound, "older copy removed")
+
+	stored, err := store.Load(STATUS_RESOLVED, task.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, task.Dependencies, stored.Dependencies, "dependency on a corrupt task kept")
+}
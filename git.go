@@ -1,10 +1,13 @@
 package dstask
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"strings"
+	"text/template"
 )
 
 // RunGitCmd shells out to git in the context of the dstask repo.
@@ -14,106 +17,213 @@ func RunGitCmd(repoPath string, args ...string) error {
 	return RunCmd("git", args...)
 }
 
-// MustRunGitCmd delegates to RunGitCmd and exits the program on any error.
-func MustRunGitCmd(repoPath string, args ...string) {
-	err := RunGitCmd(repoPath, args...)
+// CommitMessage is the data of commit message templates, see
+// Config.CommitMessages.
+type CommitMessage struct {
+	// command that made the commit, eg. add
+	Cmd string
+	// default message, eg. Added 1: buy milk
+	Message string
+	// task the commit is about, if any
+	Task Task
+	// tasks the commit is about, eg. several resolved at once. Task is not
+	// set if there are several.
+	Tasks []Task
+}
+
+// Commit is like GitCommit, except the message is rendered with the template
+// configured for the command, or the default template, if any.
+func (conf Config) Commit(cmd string, task *Task, format string, a ...any) error {
+	data := CommitMessage{
+		Cmd:     cmd,
+		Message: fmt.Sprintf(format, a...),
+	}
+
+	if task != nil {
+		data.Task = *task
+		data.Tasks = []Task{*task}
+	}
+
+	return conf.commit(data)
+}
+
+func (conf Config) commit(data CommitMessage) error {
+	msg, err := conf.renderCommitMessage(data)
 	if err != nil {
-		ExitFail("Failed to run git cmd.")
+		return err
+	}
+
+	// tasks of the bolt store are not committed, but contexts and projects
+	// are, without git's output
+	if conf.Store == STORE_BOLT {
+		fmt.Printf("\n%s\n", msg)
+
+		return GitCommitQuiet(conf.Repo, "%s", msg)
 	}
+
+	return GitCommit(conf.Repo, "%s", msg)
 }
 
-// MustGitCommit is like GitCommit, except if any error is
-// encountered, the program exits.
-func MustGitCommit(repoPath, format string, a ...any) {
-	msg := fmt.Sprintf(format, a...)
+func (conf Config) renderCommitMessage(data CommitMessage) (string, error) {
+	text, ok := conf.CommitMessages[data.Cmd]
+	if !ok {
+		text, ok = conf.CommitMessages["default"]
+	}
 
-	fmt.Printf("\n%s\n", msg)
-	fmt.Printf("\033[38;5;245m")
+	if !ok {
+		return data.Message, nil
+	}
+
+	tmpl, err := template.New(data.Cmd).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
 
-	if err := GitCommit(repoPath, format, a...); err != nil {
-		ExitFail("error: %s", err)
+	if strings.TrimSpace(buf.String()) == "" {
+		return "", fmt.Errorf("commit message template for %s is empty", data.Cmd)
 	}
 
-	fmt.Printf("\033[0m")
+	return buf.String(), nil
 }
 
-// GitCommit stages changes in the dstask repository and commits them.
+// GitCommit stages changes in the dstask repository and commits them,
+// printing the message and git's output.
 func GitCommit(repoPath, format string, a ...any) error {
 	msg := fmt.Sprintf(format, a...)
 
-	// needed before add cmd, see diff-index command
-	bins, err := os.ReadDir(path.Join(repoPath, ".git/objects"))
-	if err != nil {
-		return fmt.Errorf("failed to run git commit: %w", err)
+	fmt.Printf("\n%s\n", msg)
+
+	// git output is faded
+	if colourEnabled(os.Stdout) {
+		fmt.Print(sgr(MODE_DEFAULT, FG_FADED, COLOUR_NONE))
+		defer fmt.Print(SGR_RESET)
 	}
 
-	brandNew := len(bins) <= 2
+	return gitCommit(repoPath, msg, false)
+}
+
+// GitCommitQuiet is like GitCommit, except nothing is printed. This is
+// useful for commits made as a side effect of another command, where git's
+// output would interfere with the output of the command.
+func GitCommitQuiet(repoPath, format string, a ...any) error {
+	return gitCommit(repoPath, fmt.Sprintf(format, a...), true)
+}
+
+// runGitCmdQuiet shells out to git in the context of the dstask repo,
+// discarding output.
+func runGitCmdQuiet(repoPath string, args ...string) error {
+	args = append([]string{"-C", repoPath}, args...)
+
+	return exec.Command("git", args...).Run()
+}
+
+// gitCommit commits all changes. If quiet, git's output is discarded, and
+// nothing is printed if there are no changes.
+func gitCommit(repoPath, msg string, quiet bool) error {
+	runGitCmd := RunGitCmd
+	if quiet {
+		runGitCmd = runGitCmdQuiet
+	}
 
 	// git add all changed/created files
 	// could optimise this to be given an explicit list of
 	// added/modified/deleted files -- only if slow.
-	// tell git to stage (all) changes
-	if err = RunGitCmd(repoPath, "add", "."); err != nil {
+	// tell git to stage (all) changes, except temporary files left by a crash
+	// while writing, see writeFileAtomic
+	if err := runGitCmd(repoPath, "add", "--", ".", ":(exclude,glob)**/.*"+TEMP_FILE_INFIX+"*"); err != nil {
 		return fmt.Errorf("failed to add changes to repo: %w", err)
 	}
 
-	// check for changes -- returns exit status 1 on change. Make sure git repo
-	// has commits first, to avoid missing HEAD error.
-	if !brandNew && RunGitCmd(repoPath, "diff-index", "--quiet", "HEAD", "--") == nil {
-		fmt.Println("No changes detected")
+	// check for staged changes -- returns exit status 1 on change. Unlike
+	// diff-index, this works before the first commit.
+	if runGitCmd(repoPath, "diff", "--cached", "--quiet") == nil {
+		if !quiet {
+			fmt.Println("No changes detected")
+		}
 
 		return nil
 	}
 
-	if err = RunGitCmd(repoPath, "commit", "--no-gpg-sign", "-m", msg); err != nil {
+	if err := runGitCmd(repoPath, "commit", "--no-gpg-sign", "-m", msg); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
 	return nil
 }
 
-// MustGetRepoPath returns the full path to a file within the dstask git repo.
-// Pass file as an empty string to return the git repo directory itself.
-func MustGetRepoPath(repoPath, directory, file string) string {
+// GetRepoPath returns the full path to a file within the dstask git repo,
+// creating the directory if need be. Pass file as an empty string to return
+// the directory itself.
+func GetRepoPath(repoPath, directory, file string) (string, error) {
 	dir := path.Join(repoPath, directory)
 
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		err = os.Mkdir(dir, 0o700)
 		if err != nil {
-			ExitFail("Failed to create directory in git repository")
+			return "", fmt.Errorf("failed to create directory in git repository: %w", err)
 		}
 	}
 
-	return path.Join(dir, file)
+	return path.Join(dir, file), nil
 }
 
-// EnsureRepoExists checks for the existence of a dstask repository, or exits the program.
-func EnsureRepoExists(repoPath string) {
+// EnsureRepoExists checks for the existence of a dstask repository, creating
+// it if need be. On a terminal, the user is asked first.
+func EnsureRepoExists(repoPath string) error {
 	_, err := exec.LookPath("git")
 	if err != nil {
-		ExitFail("git required, please install")
+		return errors.New("git required, please install")
 	}
 
 	gitDotGitLocation := path.Join(repoPath, ".git")
 	if _, err := os.Stat(gitDotGitLocation); os.IsNotExist(err) {
 		if StdoutIsTTY() {
-			ConfirmOrAbort("Could not find dstask repository at %s -- create?", repoPath)
+			if err := ConfirmOrAbort("Could not find dstask repository at %s -- create?", repoPath); err != nil {
+				return err
+			}
 		}
 
 		err = os.Mkdir(repoPath, 0o700)
 		if err != nil {
-			ExitFail("Failed to create directory in git repository")
+			return fmt.Errorf("failed to create directory for git repository: %w", err)
+		}
+
+		if err := RunGitCmd(repoPath, "init"); err != nil {
+			return fmt.Errorf("failed to create git repository: %w", err)
 		}
 
-		MustRunGitCmd(repoPath, "init")
 		fmt.Println("\nAdd a remote repository with:\n\n\tdstask git remote add origin <repo>")
 		fmt.Println() // must be a separate call else compiler complains of redundant \n
 	}
+
+	return nil
+}
+
+// GitHead returns the commit at HEAD, or an empty string if there is none.
+func GitHead(repoPath string) string {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
 }
 
 // Sync performs a git pull, and then a git push. If any conflicts are encountered,
 // the user will need to resolve them.
-func Sync(repoPath string) {
-	MustRunGitCmd(repoPath, "pull", "--ff", "--no-rebase", "--no-edit", "--commit")
-	MustRunGitCmd(repoPath, "push")
+func Sync(repoPath string) error {
+	if err := RunGitCmd(repoPath, "pull", "--ff", "--no-rebase", "--no-edit", "--commit"); err != nil {
+		return fmt.Errorf("failed to pull: %w", err)
+	}
+
+	if err := RunGitCmd(repoPath, "push"); err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+
+	return nil
 }
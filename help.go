@@ -3,6 +3,7 @@ package dstask
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 func Help(cmd string) {
@@ -16,11 +17,26 @@ func Help(cmd string) {
 Usage: dstask [filter] [--]
 Example: dstask +work +bug --
 
-Display list of non-resolved tasks in the current context, most recent last,
+Display list of non-resolved tasks in the current context, most urgent first,
 optional filter. It is the default command, so "next" is unnecessary.
 
+Urgency is scored from priority, due date, age, status, dependencies and tags.
+See the README for how to change the coefficients, and the columns shown, in
+~/.dstaskrc.
+
+Blocked tasks (with unresolved dependencies) are shown last. Use :blocked or
+:unblocked to show only those tasks.
+
+Order the list by other fields with sort:, eg. sort:due,-priority,project sorts
+by due date, then by priority descending, then by project. Sort keys are id,
+priority, due, project, urgency, summary, created, resolved, age and status.
+sort: works with every command that lists tasks, and in contexts.
+
 Bypass the current context with --.
 
+A warning is shown if the deadline of the project of a listed task is within a
+week, or has passed.
+
 Colour key:
 `
 		showKey = true
@@ -62,14 +78,54 @@ To create a new task from a template use the command:
 The template task <id> remains unchanged, but a new task is created as a copy
 with any modifications made in the task summary.
 
-Github-style task lists (checklists) are recommended for templates, useful for
-performing procedures. Example:
+Subtasks, or Github-style task lists (checklists) in the notes, are recommended
+for templates, useful for performing procedures. Example:
 
 - [ ] buy bananas
 - [ ] eat bananas
 - [ ] make coffee
 
 `
+	case CMD_RECUR:
+		helpStr = `Usage: dstask recur recur:<schedule> [task summary] [--]
+Usage: dstask <id...> recur recur:<schedule>
+Example: dstask recur recur:weekly;byday=mo rotate on-call pager +oncall
+Example: dstask 12 recur recur:freq=daily;interval=3;from=completion
+
+Create a recurring task, or convert existing tasks to recurring tasks.
+Recurring tasks are hidden, and act as a template from which pending tasks are
+generated with "dstask generate".
+
+Schedules are a simplified RRULE: semicolon separated key=value rules, which
+will need quoting in most shells.
+
+freq=daily|weekly|monthly : required. daily, weekly and monthly on their own
+                            are accepted as shorthand.
+interval=<n>              : every n days, weeks or months
+byday=mo,tu,...           : weekly only, days of the week
+bymonthday=<n>            : monthly only, day of the month. Clamped to the
+                            last day of shorter months.
+from=completion           : schedule the next task n days after the previous
+                            one was resolved, rather than by the calendar
+
+Without byday or bymonthday, the day the recurring task was created is used.
+`
+	case CMD_GENERATE:
+		helpStr = `Usage: dstask generate
+
+Generate pending tasks from recurring tasks that are due, committing once. The
+generated tasks are due on the day of the occurrence. Only one open task is
+generated per recurring task; occurrences missed while a task is still open are
+skipped.
+
+Run this regularly, for instance from cron or a shell profile.
+`
+	case CMD_SHOW_RECURRING:
+		helpStr = `Usage: dstask show-recurring [filter] [--]
+
+Show a report of recurring tasks with an optional filter.
+
+Bypass the current context with --`
 	case CMD_RM, CMD_REMOVE:
 		helpStr = `Usage: dstask remove <id...>
 Example: dstask 15 remove
@@ -113,6 +169,26 @@ Usage: dstask note <id> <text>
 Example task 13 note problem is faulty hardware
 
 Edit or append text to the markdown notes attached to a particular task.
+`
+	case CMD_SUBTASK:
+		helpStr = `Usage: dstask <id> subtask <summary>
+Example: dstask 15 subtask order replacement disk
+
+Add a subtask to a task. A task cannot be resolved until all of its subtasks
+are done. Progress is shown in the task list.
+`
+	case CMD_SUBTASKS:
+		helpStr = `Usage: dstask <id> subtasks
+
+List the subtasks of a task, numbered for use with "check" and "uncheck".
+`
+	case CMD_CHECK, CMD_UNCHECK:
+		helpStr = `Usage: dstask <id> check <n...>
+Usage: dstask <id> uncheck <n...>
+Example: dstask 15 check 1 2
+
+Mark the given subtasks of a task as done, or not done. Subtasks are numbered
+from 1, as listed by "dstask <id> subtasks".
 `
 	case CMD_STOP:
 		helpStr = `Usage: dstask <id...> stop [text]
@@ -131,10 +207,63 @@ Example: dstask 15 done replaced some hardware
 
 Resolve a task. Optional text may be added, which will be appended to the note.
 `
+	case CMD_DEFER:
+		helpStr = `Usage: dstask <id...> defer <date>
+Example: dstask 15 defer next-monday
+Example: dstask 15 defer 2025-12-01
+
+Defer a task until the given date. Deferred tasks are hidden from "next" and
+"show-open" until then, at which point they become pending again. The date
+format is the same as for due dates.
+
+See deferred tasks with "dstask show-deferred".
+`
+	case CMD_DELEGATE:
+		helpStr = `Usage: dstask <id...> delegate <person>
+Example: dstask 15 delegate alice
+
+Mark a task as delegated to someone else. Delegated tasks can be filtered with
+the delegated:<person> operator, and listed per person with "dstask
+show-delegated".
+
+When the work comes back, resolve the task with "done" or return it to pending
+with "undelegate".
+`
+	case CMD_UNDELEGATE:
+		helpStr = `Usage: dstask <id...> undelegate [text]
+Example: dstask 15 undelegate needs another review
+
+Return a delegated task to pending. Optional text may be added, which will be
+appended to the note.
+`
+	case CMD_SHOW_DELEGATED:
+		helpStr = `Usage: dstask show-delegated [filter] [--]
+Example: dstask show-delegated delegated:alice
+
+Show a report of delegated tasks, grouped by the person they are delegated to.
+
+Bypass the current context with --`
+	case CMD_SHOW_OVERDUE:
+		helpStr = `Usage: dstask show-overdue [filter] [--]
+
+Show a report of open tasks that are past their due date, with an optional
+filter. Equivalent to "dstask next due:overdue".
+
+Bypass the current context with --`
+	case CMD_SHOW_DEFERRED:
+		helpStr = `Usage: dstask show-deferred [filter] [--]
+
+Show a report of deferred tasks with an optional filter.
+
+Bypass the current context with --`
 	case CMD_CONTEXT:
 		helpStr = `Usage: dstask context <filter>
+Usage: dstask context save <name> [filter]
+Usage: dstask context use <name>
+Usage: dstask context list
 Example: dstask context +work -bug
 Example: dstask context none
+Example: dstask context save work +work -bug
 
 Set a global filter consisting of a project, tags or antitags. Subsequent new
 tasks and most commands will then have this filter applied automatically.
@@ -144,6 +273,11 @@ would then have the tag "work" applied automatically.
 
 To reset to no context, run: dstask context none
 
+Named contexts are stored in the repository, so they are synchronised with
+it. "save" stores the given filter, or the current context, under a name and
+makes it active. "use" makes a named context active, and "list" shows them.
+Which context is active is not synchronised.
+
 Context can also be set with the environment variable DSTASK_CONTEXT. If set, 
 this context string will override the context stored on disk.
 `
@@ -156,12 +290,19 @@ Modify the attributes of the given tasks, specified by ID. If no ID is given,
 the operation will be performed to all tasks in the current context subject to
 confirmation.
 
-Modifiable attributes: tags, project and priority.
+Modifiable attributes: tags, project, priority, due date and dependencies.
+
+Dependencies are given by ID: dep:<id> makes the task depend on task <id>,
+-dep:<id> removes the dependency. A task with unresolved dependencies is
+blocked, and shown after other tasks. Dependency cycles are refused.
+
+Example: dstask 34 modify dep:12 dep:13
 `
 	case CMD_EDIT:
 		helpStr = `Usage: dstask <id...> edit
 
-Edit a task in your text editor.
+Edit a task in your text editor. The editor is DSTASK_EDITOR, editor in
+~/.dstaskrc or EDITOR, in that order.
 `
 	case CMD_UNDO:
 		helpStr = `Usage: dstask undo
@@ -174,6 +315,20 @@ Undo the last <n> commits on the repository. Default is 1. Use
 To see commit history. For more complicated history manipulation it may be best
 to revert/rebase/merge on the dstask repository itself. The dstask repository
 is at ~/.dstask by default.
+`
+	case CMD_FSCK:
+		helpStr = `Usage: dstask fsck [--fix]
+
+Check the repository for tasks that can't be loaded, such as malformed YAML or
+files not named by UUID, tasks stored with several statuses, dependencies on
+tasks that don't exist, invalid priorities and fields that don't match the
+status of a task, such as a resolved task with no resolved time.
+
+With --fix, the problems that can be repaired are, in a single commit: the
+newest copy of a task is kept, dependencies on missing tasks are removed,
+invalid priorities are set to P2, and resolved times are set from when the
+task was last saved, or cleared. Other problems must be fixed by hand, eg. with
+"dstask git".
 `
 	case CMD_SYNC:
 		helpStr = `Usage: dstask sync
@@ -209,7 +364,54 @@ this command.
 	case CMD_SHOW_PROJECTS:
 		helpStr = `Usage: dstask show-projects
 
-Show a breakdown of projects with progress information
+Show a breakdown of projects with progress information. Projects are
+hierarchical, separated by dots, eg. project:infra.k8s. They are shown as a
+tree, with progress and priority rolled up from sub-projects.
+
+The deadline, owner and description of projects are shown if set with
+"dstask project". Archived projects are not shown.
+`
+	case CMD_REPORT:
+		helpStr = `Usage: dstask report [name] [filter] [--]
+Example: dstask report stale
+Example: dstask report stale project:website
+
+Run a named report, or list the reports if no name is given. Reports are
+defined in ~/.dstaskrc, each with an optional filter, sort order, columns and
+statuses:
+
+[reports.stale]
+description = "Oldest work first"
+filter = "+work"
+sort = ["created", "-priority"]
+columns = ["id", "age", "priority", "project", "summary"]
+statuses = ["pending", "paused"]
+
+Sort keys are id, priority, due, project, urgency, summary, created,
+resolved, age and status. Prefix a key with - to sort in descending order.
+Columns are the sort keys plus tags, progress, notes (number of lines) and
+uuid (the first 8 characters).
+
+The filter given is combined with the filter of the report and the current
+context. Bypass the current context with --.
+
+A sort: operator, eg. sort:-due, overrides the sort order of the report.
+`
+	case CMD_PROJECT:
+		helpStr = `Usage: dstask project <name> [owner:<name>] [deadline:<date>] [archive|unarchive] [description]
+Example: dstask project infra.k8s owner:alice deadline:2026-12-01 Migrate services to k8s
+Example: dstask project infra.k8s deadline:none
+Example: dstask project website archive
+
+Show or update the record of a project. Records are stored in the projects
+directory of the repository, so they are synchronised with it.
+
+Deadlines take the same formats as due dates. "dstask next" warns when the
+deadline of a project of a listed task is within a week. Use deadline:none to
+clear it.
+
+Archived projects, and their sub-projects, are hidden from show-projects and
+completion. Their tasks are unaffected.
 `
 
 	case CMD_PRINT_BASH_COMPLETION, CMD_PRINT_ZSH_COMPLETION, CMD_PRINT_FISH_COMPLETION:
@@ -229,10 +431,16 @@ Where [task summary] is text with tags/project/priority specified. Tags are
 specified with + (or - for filtering) eg: +work. The project is specified with
 a project:g prefix eg: project:dstask -- no quotes. Priorities run from P3
 (low), P2 (default) to P1 (high) and P0 (critical). Text can also be specified
-for a substring search of description and notes.
+to search the summary and notes, from the start of words. summary:<text> and
+notes:<text> search one field, and /regex/ is a regular expression search.
+
+Filters can be combined with and, or, not and parentheses, eg:
+'(+bug or +incident) and not project:infra'. Priorities can be compared, eg:
+'priority<=P1'. Quote these from the shell.
 
 Cmd and IDs can be swapped, multiple IDs can be specified for batch
-operations.
+operations, which are committed together. If any task can't be changed, none
+are.
 
 run "dstask help <cmd>" for command specific help.
 
@@ -244,15 +452,27 @@ Available commands:
 next              : Show most important tasks (priority, creation date -- truncated and default)
 add               : Add a task
 template          : Add a task template
+recur             : Add a recurring task
+generate          : Generate tasks from recurring tasks that are due
 log               : Log a task (already resolved)
 start             : Change task status to active
 note              : Append to or edit note for a task
+subtask           : Add a subtask to a task
+subtasks          : List the subtasks of a task
+check             : Mark subtasks as done
+uncheck           : Mark subtasks as not done
 stop              : Change task status to pending
 done              : Resolve a task
+defer             : Hide a task until a given date
+delegate          : Delegate a task to someone else
+undelegate        : Return a delegated task to pending
 context           : Set global context for task list and new tasks (use "none" to set no context)
+project           : Show or set the description, owner, deadline of a project, or archive it
+report            : Run a named report defined in ~/.dstaskrc, or list them
 modify            : Change task attributes specified on command line
 edit              : Edit task with text editor
 undo              : Undo last n commits
+fsck              : Check the repository for problems, --fix to repair them
 sync              : Pull then push to git repository, automatic merge commit.
 open              : Open all URLs found in summary/annotations
 git               : Pass a command to git in the repository. Used for push/pull.
@@ -264,6 +484,10 @@ show-paused       : Show tasks that have been started then stopped
 show-open         : Show all non-resolved tasks (without truncation)
 show-resolved     : Show resolved tasks
 show-templates    : Show task templates
+show-recurring    : Show recurring tasks
+show-deferred     : Show deferred tasks
+show-overdue      : Show tasks that are past their due date
+show-delegated    : Show delegated tasks, grouped by delegate
 show-unorganised  : Show untagged tasks with no projects (global context)
 bash-completion   : Print bash completion script to stdout
 fish-completion   : Print fish completion script to stdout
@@ -271,6 +495,16 @@ zsh-completion    : Print zsh completion script to stdout
 help              : Get help on any command or show this message
 version           : Show dstask version information
 
+Options:
+
+--color=<when>    : Colour output always, never or auto (only to a terminal)
+--format=<format> : Output of listings as table, json, ndjson, csv, tsv or yaml.
+                    By default, a table on a terminal and json otherwise
+--template=<text> : Output of listings as a Go text/template per item, eg.
+                    '{{.ID}} {{.Summary}}', or the name of one in the config
+
+Options after the git command are passed to git.
+
 Colour Key:
 `
 
@@ -280,18 +514,36 @@ Colour Key:
 	fmt.Fprint(os.Stderr, helpStr)
 
 	if showKey {
-		colourPrintln(0, FG_PRIORITY_CRITICAL, BG_DEFAULT_2, "Critical priority")
-		colourPrintln(0, FG_PRIORITY_HIGH, BG_DEFAULT_2, "High priority")
-		colourPrintln(0, FG_DEFAULT, BG_DEFAULT_1, "Normal priority")
-		colourPrintln(0, FG_PRIORITY_LOW, BG_DEFAULT_2, "Low priority")
-		colourPrintln(0, FG_ACTIVE, BG_ACTIVE, "Active")
-		colourPrintln(0, FG_DEFAULT, BG_PAUSED, "Paused")
+		yesterday := time.Now().AddDate(0, 0, -1)
+
+		colourPrintln(&Task{Priority: PRIORITY_CRITICAL}, BG_DEFAULT_2, "Critical priority")
+		colourPrintln(&Task{Priority: PRIORITY_HIGH}, BG_DEFAULT_2, "High priority")
+		colourPrintln(&Task{Priority: PRIORITY_NORMAL}, BG_DEFAULT_1, "Normal priority")
+		colourPrintln(&Task{Priority: PRIORITY_LOW}, BG_DEFAULT_2, "Low priority")
+		colourPrintln(&Task{Priority: PRIORITY_NORMAL, Due: yesterday}, BG_DEFAULT_1, "Overdue")
+		colourPrintln(&Task{Priority: PRIORITY_NORMAL, Status: STATUS_ACTIVE}, BG_DEFAULT_1, "Active")
+		colourPrintln(&Task{Priority: PRIORITY_NORMAL, Status: STATUS_PAUSED}, BG_DEFAULT_1, "Paused")
 	}
 
 	os.Exit(0)
 }
 
-func colourPrintln(mode, fg, bg int, line string) {
+// colourPrintln prints a line of the colour key in the style of the task, on
+// the given background unless the style has one.
+func colourPrintln(t *Task, bg int, line string) {
 	line = FixStr(line, 25)
-	fmt.Fprintf(os.Stderr, "\033[%d;38;5;%d;48;5;%dm%s\033[0m\n", mode, fg, bg, line)
+
+	if !colourEnabled(os.Stderr) {
+		fmt.Fprintln(os.Stderr, line)
+
+		return
+	}
+
+	style := t.Style()
+
+	if style.Bg == 0 {
+		style.Bg = bg
+	}
+
+	fmt.Fprintln(os.Stderr, sgr(style.Mode, style.Fg, style.Bg)+line+SGR_RESET)
 }
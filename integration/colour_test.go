@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColour(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "alpha", "P1", "/", "a note")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "bravo", "+work")
+	assertProgramResult(t, output, exiterr, success)
+
+	// no escapes when redirected
+	assert.NotContains(t, string(output), "\033[")
+
+	_, exiterr, success = program("99", "done")
+	assert.False(t, success)
+	assert.NotContains(t, string(exiterr.Stderr), "\033[")
+
+	defer setEnv("DSTASK_FAKE_PTY", "1")()
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "\033[0;38;5;166;48;5;")
+
+	output, exiterr, success = program("next", "--color=never")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "alpha")
+	assert.Contains(t, string(output), "a note")
+	assert.NotContains(t, string(output), "\033[")
+
+	// only bold, reverse video etc.
+	func() {
+		defer setEnv("NO_COLOR", "1")()
+
+		output, exiterr, success = program("next")
+		assertProgramResult(t, output, exiterr, success)
+		assert.Contains(t, string(output), "\033[4m")
+		assert.NotContains(t, string(output), "38;5")
+	}()
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte(`theme = "16-colour"`), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "\033[0;33m")
+	assert.NotContains(t, string(output), "38;5")
+
+	_, _, success = program("next", "--color=sometimes")
+	assert.False(t, success)
+}
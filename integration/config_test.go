@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFile(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte(`
+context = "+work"
+columns = ["id", "urgency", "summary"]
+
+[commit_messages]
+add = "dstask: {{.Message}}"
+`), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two", "--")
+	assertProgramResult(t, output, exiterr, success)
+
+	out, err := exec.Command("git", "-C", repo, "log", "-1", "--format=%s").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "dstask: Added 2: two", strings.TrimSpace(string(out)))
+
+	// the default context applies until a context is set
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, []string{"work"}, tasks[0].Tags)
+
+	output, exiterr, success = program("context", "none")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	defer setEnv("DSTASK_FAKE_PTY", "1")()
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+	// urgency of one, with a tag
+	assert.Contains(t, string(output), "4.7")
+	assert.NotContains(t, string(output), "P2", "no priority column")
+}
+
+func TestConfigFileInvalid(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte(`columns = ["id", "colour"]`), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	_, exiterr, success := program("next")
+	assert.False(t, success)
+	assert.Contains(t, string(exiterr.Stderr), "invalid column: colour")
+}
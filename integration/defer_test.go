@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeferHidesTaskUntilWaitDate(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "defer", "tomorrow")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "two", tasks[0].Summary)
+
+	output, exiterr, success = program("show-deferred")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "one", tasks[0].Summary)
+	assertDateEqual(t, getRelativeDate(1), tasks[0].Wait)
+
+	// simulate the passing of time
+	path := filepath.Join(repo, "deferred", tasks[0].UUID+".yml")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	tomorrow := getRelativeDate(1).Format("2006-01-02")
+	yesterday := getRelativeDate(-1).Format("2006-01-02")
+	data = []byte(strings.ReplaceAll(string(data), tomorrow, yesterday))
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	output, exiterr, success = program("git", "commit", "--quiet", "--all", "--message", "time passes")
+	assertProgramResult(t, output, exiterr, success)
+
+	head, exiterr, success := program("git", "rev-parse", "HEAD")
+	assertProgramResult(t, head, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2, "deferred task should be promoted")
+
+	// the promotion isn't written by a read-only command
+	assert.FileExists(t, path)
+
+	output, exiterr, success = program("git", "status", "--porcelain")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Empty(t, string(output))
+
+	output, exiterr, success = program("git", "rev-parse", "HEAD")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, string(head), string(output), "nothing committed")
+
+	// but is with the next change of the task
+	output, exiterr, success = program("1", "modify", "+woken")
+	assertProgramResult(t, output, exiterr, success)
+
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, filepath.Join(repo, "pending", filepath.Base(path)))
+}
+
+func TestDeferRequiresFutureDate(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	_, _, success = program("1", "defer", "yesterday")
+	assert.False(t, success)
+}
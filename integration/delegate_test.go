@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/naggie/dstask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegateAndUndelegate(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "three")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "delegate", "Bob")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("2", "3", "delegate", "Alice")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-delegated")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, "Alice", tasks[0].DelegatedTo, "grouped by delegate")
+	assert.Equal(t, "Alice", tasks[1].DelegatedTo, "grouped by delegate")
+	assert.Equal(t, "Bob", tasks[2].DelegatedTo, "grouped by delegate")
+	assert.Equal(t, dstask.STATUS_DELEGATED, tasks[2].Status)
+
+	output, exiterr, success = program("next", "delegated:alice")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	output, exiterr, success = program("1", "undelegate")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("2", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-delegated")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "three", tasks[0].Summary)
+
+	output, exiterr, success = program("1")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Equal(t, dstask.STATUS_PENDING, tasks[0].Status)
+	assert.Empty(t, tasks[0].DelegatedTo)
+	assert.Empty(t, tasks[0].Notes, "no note given")
+
+	// delegate is only set by the delegate command
+	_, _, success = program("1", "modify", "delegated:bob")
+	assert.False(t, success)
+
+	_, _, success = program("add", "four", "delegated:bob")
+	assert.False(t, success)
+
+	_, _, success = program("1", "undelegate")
+	assert.False(t, success, "task 1 is no longer delegated")
+}
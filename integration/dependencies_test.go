@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependenciesBlockTasks(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one", "P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "modify", "dep:2")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Equal(t, "two", tasks[0].Summary, "blocked task shown last despite priority")
+	assert.Equal(t, "one", tasks[1].Summary)
+	assert.True(t, tasks[1].Blocked)
+
+	output, exiterr, success = program("next", ":blocked")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "one", tasks[0].Summary)
+
+	// cycles are refused
+	_, _, success = program("2", "modify", "dep:1")
+	assert.False(t, success)
+
+	output, exiterr, success = program("2", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", ":unblocked")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "one", tasks[0].Summary)
+	assert.False(t, tasks[0].Blocked)
+}
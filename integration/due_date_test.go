@@ -237,10 +237,83 @@ func TestFilterTasksByOverdue(t *testing.T) {
 	output, exiterr, success = program("next", "due:overdue")
 	assertProgramResult(t, output, exiterr, success)
 
+	// a task due today without a time is not overdue until tomorrow
 	tasks := unmarshalTaskArray(t, output)
-	assert.Len(t, tasks, 2)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Overdue task", tasks[0].Summary)
+}
+
+func TestFilterTasksDueWithin(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "Overdue task", "due:yesterday")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Soon task", "due:+3d")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Later task", "due:+3w")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "due.within:7d")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Soon task", tasks[0].Summary)
+}
+
+func TestFilterTasksDueRange(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "Task 1", "due:2025-06-01")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Task 2", "due:2025-06-15")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Task 3", "due:2025-07-01")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "due.after:2025-06-10", "due.before:2025-06-20")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Task 2", tasks[0].Summary)
+
+	// only a range may have two due dates
+	_, _, success = program("next", "due.after:2025-06-10", "due.after:2025-06-20")
+	assert.False(t, success)
+}
+
+func TestShowOverdue(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "Overdue task", "due:yesterday")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Future task", "due:tomorrow")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Task without due date")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-overdue")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
 	assert.Equal(t, "Overdue task", tasks[0].Summary)
-	assert.Equal(t, "Today task", tasks[1].Summary)
 }
 
 func TestFilterTasksByThisWeekdays(t *testing.T) {
@@ -504,6 +577,64 @@ func TestShowResolvedDisplaysDueDates(t *testing.T) {
 	assertDateEqual(t, getCurrentDate(), resolvedTasks[0].Due)
 }
 
+func TestAddTaskWithDueTime(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "Task with time", "due:tomorrow-9am")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Task with ISO time", "due:2025-07-01T15:00")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "due:tomorrow")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Task with time", tasks[0].Summary)
+	assert.Equal(t, 9, tasks[0].Due.Local().Hour())
+
+	output, exiterr, success = program("next", "due.on:2025-07-01")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Task with ISO time", tasks[0].Summary)
+	assert.Equal(t, 15, tasks[0].Due.Local().Hour())
+}
+
+func TestAddTaskWithRelativeDates(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "Task in three days", "due:+3d")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "Task in two weeks", "due:+2w")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "due.before:+1w")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Task in three days", tasks[0].Summary)
+	assertDateEqual(t, getRelativeDate(3), tasks[0].Due)
+
+	output, exiterr, success = program("next", "due.after:+1w")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Task in two weeks", tasks[0].Summary)
+	assertDateEqual(t, getRelativeDate(14), tasks[0].Due)
+}
+
 func TestInvalidDateFormats(t *testing.T) {
 	repo, cleanup := makeDstaskRepo(t)
 	defer cleanup()
@@ -519,6 +650,10 @@ func TestInvalidDateFormats(t *testing.T) {
 		"due:next-funday",
 		"due:this-xyz",
 		"due.afber:today",
+		"due:today-25:00",
+		"due:tomorrow-9",
+		"due:+3x",
+		"due:6th-monday",
 	}
 
 	failedCount := 0
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/naggie/dstask"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestFormat(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "alpha, with a comma", "+work", "project:web", "/", "line 1\nline 2")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "bravo", "+work", "+home", "dep:1")
+	assertProgramResult(t, output, exiterr, success)
+
+	// JSON includes dependencies
+	output, exiterr, success = program("next", "--format=json")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "bravo", tasks[1].Summary)
+	assert.Equal(t, []string{tasks[0].UUID}, tasks[1].Dependencies)
+
+	output, exiterr, success = program("next", "--format=ndjson")
+	assertProgramResult(t, output, exiterr, success)
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Len(t, lines, 2)
+
+	var task dstask.Task
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &task))
+	assert.Equal(t, "alpha, with a comma", task.Summary)
+
+	output, exiterr, success = program("next", "--format=csv")
+	assertProgramResult(t, output, exiterr, success)
+
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "uuid", records[0][0])
+	assert.Equal(t, "alpha, with a comma", records[1][3])
+	assert.Equal(t, "line 1\nline 2", records[1][4])
+
+	output, exiterr, success = program("next", "--format=tsv")
+	assertProgramResult(t, output, exiterr, success)
+
+	lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "\tline 1\\nline 2\t")
+
+	output, exiterr, success = program("next", "--format=yaml")
+	assertProgramResult(t, output, exiterr, success)
+
+	var values []map[string]any
+	assert.NoError(t, yaml.Unmarshal(output, &values))
+	assert.Len(t, values, 2)
+	assert.Equal(t, "bravo", values[1]["summary"])
+
+	output, exiterr, success = program("show-tags", "--format=json")
+	assertProgramResult(t, output, exiterr, success)
+
+	var tags []dstask.Tag
+	assert.NoError(t, json.Unmarshal(output, &tags))
+	assert.Equal(t, []dstask.Tag{{Name: "home", Tasks: 1}, {Name: "work", Tasks: 2}}, tags)
+
+	output, exiterr, success = program("show-projects", "--format=csv")
+	assertProgramResult(t, output, exiterr, success)
+
+	records, err = csv.NewReader(bytes.NewReader(output)).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "web", records[1][0])
+
+	// a table, without colour, even if redirected
+	output, exiterr, success = program("show-open", "--format=table")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "bravo")
+	assert.Contains(t, string(output), "2 tasks.")
+	assert.NotContains(t, string(output), "\033[")
+
+	_, _, success = program("next", "--format=xml")
+	assert.False(t, success)
+}
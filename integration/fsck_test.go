@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFsck(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one", "P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("fsck")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "No problems found")
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	uuid := unmarshalTaskArray(t, output)[0].UUID
+	path := filepath.Join(repo, "pending", uuid+".yml")
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), "P1", "P9", 1)), 0o600))
+
+	output, _, success = program("fsck")
+	assert.False(t, success)
+	assert.Contains(t, string(output), `invalid priority "P9", setting P2`)
+
+	output, exiterr, success = program("fsck", "--fix")
+	assertProgramResult(t, output, exiterr, success)
+
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "priority: P2")
+
+	out, err := exec.Command("git", "-C", repo, "log", "-1", "--format=%s").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "Fixed 1 problem(s)\n", string(out))
+
+	output, exiterr, success = program("fsck")
+	assertProgramResult(t, output, exiterr, success)
+}
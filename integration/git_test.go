@@ -0,0 +1,27 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitPassthrough(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	// --format is an option of git log, not dstask
+	output, exiterr, success = program("git", "log", "--format=%s")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, "Added 1: one\n", string(output))
+
+	// and --color, which would otherwise be taken by dstask
+	output, exiterr, success = program("git", "log", "--color=always", "--oneline")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Contains(t, string(output), "\033[")
+}
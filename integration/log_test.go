@@ -24,3 +24,38 @@ func TestLog(t *testing.T) {
 	tasks := unmarshalTaskArray(t, output)
 	assert.Equal(t, "two", tasks[0].Summary, "task two should be resolved")
 }
+
+func TestSummaryWithExpressionWords(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "call", "bob", "(not", "urgent)", "+work")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "ask", "about", "+work", "or", "+home")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("log", "call", "bob", "(not", "urgent)", "+work")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("log", "ask", "about", "+work", "or", "+home")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Equal(t, 2, len(tasks))
+
+	summaries := map[string]bool{tasks[0].Summary: true, tasks[1].Summary: true}
+	assert.Assert(t, summaries["call bob (not urgent)"], summaries)
+	assert.Assert(t, summaries["ask about or"], summaries)
+
+	output, exiterr, success = program("show-resolved")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Equal(t, 2, len(tasks))
+}
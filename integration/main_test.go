@@ -55,6 +55,10 @@ func testCmd(repoPath string) func(args ...string) ([]byte, *exec.ExitError, boo
 	return func(args ...string) ([]byte, *exec.ExitError, bool) {
 		cmd := exec.Command(binaryPath(), args...)
 		env := os.Environ()
+		// isolate from the config file of the user, unless a test sets one
+		if os.Getenv("DSTASK_CONFIG") == "" {
+			env = append(env, "DSTASK_CONFIG="+os.DevNull)
+		}
 		cmd.Env = append(env, "DSTASK_GIT_REPO="+repoPath)
 		output, err := cmd.Output()
 		exitErr := &exec.ExitError{}
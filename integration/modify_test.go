@@ -27,15 +27,20 @@ func TestModifyTasksByID(t *testing.T) {
 	output, exiterr, success = program("next")
 	assertProgramResult(t, output, exiterr, success)
 
-	tasks := unmarshalTaskArray(t, output)
+	// ordered by urgency, which counts tags
+	tags := make(map[string][]string)
+	for _, task := range unmarshalTaskArray(t, output) {
+		tags[task.Summary] = task.Tags
+	}
+
 	assert.ElementsMatch(
 		t,
 		[]string{"three", "extra"},
-		tasks[2].Tags,
+		tags["three"],
 		"extra tag added to task three",
 	)
-	assert.ElementsMatch(t, []string{"two", "extra"}, tasks[1].Tags, "extra tag added to task two")
-	assert.ElementsMatch(t, []string{"one"}, tasks[0].Tags, "task 1 not modified")
+	assert.ElementsMatch(t, []string{"two", "extra"}, tags["two"], "extra tag added to task two")
+	assert.ElementsMatch(t, []string{"one"}, tags["one"], "task 1 not modified")
 }
 
 func TestModifyTasksInContext(t *testing.T) {
@@ -1,7 +1,9 @@
 package integration
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/naggie/dstask"
@@ -114,3 +116,72 @@ func TestContextFromEnvVar(t *testing.T) {
 	tasks = unmarshalTaskArray(t, output)
 	assert.Equal(t, "two", tasks[0].Summary, "project:beta is on-disk context")
 }
+
+func TestNamedContexts(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "+one", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "+two", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("context", "save", "first", "+one")
+	assertProgramResult(t, output, exiterr, success)
+
+	// saving a context makes it active
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "one", tasks[0].Summary)
+
+	// the current context is saved if no filter is given
+	output, exiterr, success = program("context", "+two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("context", "save", "second")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("context", "use", "first")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("context", "list")
+	assertProgramResult(t, output, exiterr, success)
+
+	var contexts []dstask.NamedContext
+
+	assert.NoError(t, json.Unmarshal(output, &contexts))
+	assert.Equal(t, []dstask.NamedContext{
+		{Name: "first", Context: "+one", Active: true},
+		{Name: "second", Context: "+two", Active: false},
+	}, contexts)
+
+	// named contexts are stored in the repository
+	_, err := os.Stat(filepath.Join(repo, dstask.CONTEXTS_FILENAME))
+	assert.NoError(t, err)
+
+	output, exiterr, success = program("context", "none")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	_, _, success = program("context", "use", "missing")
+	assert.False(t, success)
+
+	// relative dates are saved as given, not as the date they are now
+	output, exiterr, success = program("context", "save", "soon", "due.within:7d")
+	assertProgramResult(t, output, exiterr, success)
+
+	data, err := os.ReadFile(filepath.Join(repo, dstask.CONTEXTS_FILENAME))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "soon: due.within:7d")
+}
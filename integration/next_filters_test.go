@@ -90,3 +90,65 @@ func TestNextProjectFilter(t *testing.T) {
 	tasks = unmarshalTaskArray(t, output)
 	assert.Equal(t, "one", tasks[0].Summary)
 }
+
+func TestNextFilterExpression(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "+bug", "project:web", "web bug")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "+incident", "P1", "outage")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "+bug", "project:infra", "infra bug")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "+feature", "P0", "feature")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "(+bug or +incident) and not project:infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "outage", tasks[0].Summary)
+	assert.Equal(t, "web bug", tasks[1].Summary)
+
+	output, exiterr, success = program("next", "priority<=P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "feature", tasks[0].Summary)
+	assert.Equal(t, "outage", tasks[1].Summary)
+
+	// an expression can be saved as a context
+	output, exiterr, success = program("context", "+bug", "or", "+feature")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "not", "project:infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "feature", tasks[0].Summary)
+	assert.Equal(t, "web bug", tasks[1].Summary)
+
+	// the words of a new task are not an expression
+	output, exiterr, success = program("add", "+bug", "or", "+feature", "ambiguous")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "--", "+bug", "+feature")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "or ambiguous", tasks[0].Summary)
+
+	// filter expressions cannot be used to set attributes
+	_, _, success = program("modify", "1", "+bug", "or", "+feature")
+	assert.False(t, success)
+}
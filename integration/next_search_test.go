@@ -71,3 +71,56 @@ func TestNextSearchCaseInsensitive(t *testing.T) {
 	tasks = unmarshalTaskArray(t, output)
 	assert.Len(t, tasks, 1, `string "ALPHA" should find notes field containing "alpha"`)
 }
+
+func TestNextSearchFields(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "deploy", "web", "/", "server", "config")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "server", "upgrade")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "observe", "metrics")
+	assertProgramResult(t, output, exiterr, success)
+
+	// plain text matches from the start of a word, in summary or notes
+	output, exiterr, success = program("next", "serv")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	// but not across summary and notes
+	output, exiterr, success = program("next", "web server")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Empty(t, tasks)
+
+	output, exiterr, success = program("next", "summary:server")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "server upgrade", tasks[0].Summary)
+
+	output, exiterr, success = program("next", "notes:server")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "deploy web", tasks[0].Summary)
+
+	output, exiterr, success = program("next", "/serv(er|e)/")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 3)
+
+	_, _, success = program("next", "/serv(/")
+	assert.False(t, success, "invalid regex")
+}
@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextUrgency(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one", "P3")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two", "P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "three", "P3", "+next")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Equal(t, "two", tasks[0].Summary)
+	assert.InDelta(t, 6.0, tasks[0].Urgency, 0.01)
+	assert.Equal(t, "three", tasks[1].Summary, "tags add urgency")
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte("[urgency.tag]\nnext = 15.0\n"), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Equal(t, "three", tasks[0].Summary)
+	assert.InDelta(t, 1.8+0.8+15.0, tasks[0].Urgency, 0.01)
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurGenerate(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("recur", "recur:daily", "check", "alerts", "+oncall")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Empty(t, tasks, "recurring tasks are hidden")
+
+	output, exiterr, success = program("show-recurring")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "freq=daily", tasks[0].Recur)
+
+	parent := tasks[0].UUID
+
+	output, exiterr, success = program("generate")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "check alerts", tasks[0].Summary)
+	assert.Equal(t, []string{"oncall"}, tasks[0].Tags)
+	assert.Equal(t, parent, tasks[0].Parent)
+	assertDateEqual(t, getCurrentDate(), tasks[0].Due)
+
+	// already generated today
+	output, exiterr, success = program("generate")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+}
+
+func TestRecurRequiresSchedule(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("recur", "check", "alerts")
+	assert.False(t, success)
+
+	output, exiterr, success = program("add", "check", "alerts")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "recur", "recur:weekly;byday=mo")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-recurring")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Equal(t, "freq=weekly;byday=mo", tasks[0].Recur)
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte(`
+[reports.work]
+filter = "+work"
+sort = ["-priority", "summary"]
+
+[reports.done]
+sort = ["summary"]
+statuses = ["resolved"]
+`), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success := program("add", "alpha", "+work", "P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "bravo", "+work", "P3")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "charlie", "+work", "P3", "project:web")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "delta", "+home")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("report", "work")
+	assertProgramResult(t, output, exiterr, success)
+
+	var summaries []string
+	for _, task := range unmarshalTaskArray(t, output) {
+		summaries = append(summaries, task.Summary)
+	}
+
+	assert.Equal(t, []string{"bravo", "charlie", "alpha"}, summaries)
+
+	// with an extra filter
+	output, exiterr, success = program("report", "work", "project:web")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "charlie", tasks[0].Summary)
+
+	output, exiterr, success = program("4", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("report", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "delta", tasks[0].Summary)
+
+	_, _, success = program("report", "missing")
+	assert.False(t, success)
+}
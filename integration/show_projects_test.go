@@ -1,8 +1,10 @@
 package integration
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/naggie/dstask"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,3 +40,97 @@ func TestShowProjects(t *testing.T) {
 	assert.Equal(t, 1, projects[0].TasksResolved, "no tasks resolved")
 	assert.Equal(t, 3, projects[0].Tasks, "three tasks created")
 }
+
+func TestShowProjectsHierarchy(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one", "project:infra.k8s.ingress", "P1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two", "project:infra.db")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "three", "project:web")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-projects")
+	assertProgramResult(t, output, exiterr, success)
+
+	projects := unmarshalProjectArray(t, output)
+
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+
+	assert.Equal(t, []string{"infra", "infra.db", "infra.k8s", "infra.k8s.ingress", "web"}, names)
+	assert.Equal(t, 2, projects[0].Tasks, "rolled up from descendants")
+	assert.Equal(t, "P1", projects[0].Priority, "rolled up from descendants")
+	assert.Equal(t, "infra", projects[2].Parent)
+
+	// a project includes its descendants
+	output, exiterr, success = program("next", "project:infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	output, exiterr, success = program("next", "-project:infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "three", tasks[0].Summary)
+}
+
+func TestProjectRecords(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one", "project:infra.k8s")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two", "project:web")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("project", "infra", "owner:alice", "deadline:2026-12-01", "Infrastructure", "work")
+	assertProgramResult(t, output, exiterr, success)
+
+	// project names are lowercase, like those of tasks
+	output, exiterr, success = program("project", "Web", "archive")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("project", "infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	var record dstask.ProjectRecord
+
+	assert.NoError(t, json.Unmarshal(output, &record))
+	assert.Equal(t, "alice", record.Owner)
+	assert.Equal(t, "Infrastructure work", record.Description)
+	assert.Equal(t, "2026-12-01", record.Deadline.Format("2006-01-02"))
+
+	output, exiterr, success = program("show-projects")
+	assertProgramResult(t, output, exiterr, success)
+
+	projects := unmarshalProjectArray(t, output)
+	assert.Len(t, projects, 3)
+	assert.Equal(t, "alice", projects[0].Owner)
+	assert.Equal(t, "", projects[1].Owner, "records are not inherited")
+	assert.True(t, projects[2].Archived)
+
+	output, exiterr, success = program("project", "infra", "deadline:none")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("project", "infra")
+	assertProgramResult(t, output, exiterr, success)
+
+	assert.NoError(t, json.Unmarshal(output, &record))
+	assert.True(t, record.Deadline.IsZero())
+	assert.Equal(t, "alice", record.Owner, "unchanged")
+}
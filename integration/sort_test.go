@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortOperator(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "alpha", "P3", "project:web", "due:2030-01-02")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "bravo", "P1", "project:api")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "charlie", "P3", "project:api", "due:2030-01-02")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "delta", "P2", "project:web", "due:2030-01-01")
+	assertProgramResult(t, output, exiterr, success)
+
+	summaries := func(output []byte) []string {
+		var summaries []string
+		for _, task := range unmarshalTaskArray(t, output) {
+			summaries = append(summaries, task.Summary)
+		}
+
+		return summaries
+	}
+
+	// tasks without a due date last
+	output, exiterr, success = program("next", "sort:due,-priority,project")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, []string{"delta", "charlie", "alpha", "bravo"}, summaries(output))
+
+	output, exiterr, success = program("show-open", "sort:-summary")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, []string{"delta", "charlie", "bravo", "alpha"}, summaries(output))
+
+	// the sort order of the command line wins over the context
+	output, exiterr, success = program("context", "project:web", "sort:summary")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, []string{"alpha", "delta"}, summaries(output))
+
+	output, exiterr, success = program("next", "sort:-summary")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, []string{"delta", "alpha"}, summaries(output))
+
+	output, exiterr, success = program("context", "none")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("3", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("show-resolved", "sort:-summary")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, []string{"charlie", "alpha"}, summaries(output))
+
+	_, _, success = program("next", "sort:colour")
+	assert.False(t, success)
+
+	_, _, success = program("add", "echo", "sort:due")
+	assert.False(t, success)
+}
@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStore(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	assert.NoError(t, os.WriteFile(config, []byte(`store = "bolt"`), 0o600))
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "done")
+	assertProgramResult(t, output, exiterr, success)
+
+	assert.FileExists(t, filepath.Join(repo, ".git", "dstask", "tasks.db"))
+	assert.NoDirExists(t, filepath.Join(repo, "pending"), "no YAML files")
+
+	out, err := exec.Command("git", "-C", repo, "ls-files").Output()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "tasks.db", "database not committed")
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "two", tasks[0].Summary)
+
+	output, exiterr, success = program("show-resolved")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks = unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "one", tasks[0].Summary)
+}
+
+func TestReconcileDuplicateTask(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	uuid := unmarshalTaskArray(t, output)[0].UUID
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+	pending := filepath.Join(repo, "pending", uuid+".yml")
+
+	// as if interrupted while resolving it: the resolved copy is newer. The
+	// file of the pending copy is newer, as after a git checkout, but that's
+	// not used.
+	data, err := os.ReadFile(pending)
+	assert.NoError(t, err)
+
+	modified := regexp.MustCompile(`(?m)^modified: .*$`)
+	assert.Regexp(t, modified, string(data))
+
+	resolvedData := modified.ReplaceAllString(
+		string(data),
+		"modified: "+time.Now().Add(time.Minute).Format(time.RFC3339Nano),
+	) + "resolved: 2026-10-18T09:00:00Z\n"
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(repo, "resolved"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, "resolved", uuid+".yml"), []byte(resolvedData), 0o600))
+
+	hourLater := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(pending, hourLater, hourLater))
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "two", tasks[0].Summary)
+
+	assert.NoFileExists(t, pending)
+
+	out, err := exec.Command("git", "-C", repo, "status", "--porcelain").Output()
+	assert.NoError(t, err)
+	assert.Empty(t, string(out), "reconciled copy committed")
+}
+
+func TestTempFilesNotCommitted(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	// as if interrupted while writing a task
+	temp := filepath.Join(repo, "pending", ".5b0c8e3e-9d1f-4a7c-8e2b-6f1a2b3c4d01.yml.tmp-123")
+	assert.NoError(t, os.WriteFile(temp, []byte("summary: two\n"), 0o600))
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	out, err := exec.Command("git", "-C", repo, "ls-files", "pending").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(out), "\n"))
+	assert.NotContains(t, string(out), ".tmp-")
+}
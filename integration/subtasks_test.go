@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/naggie/dstask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtasks(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "replace", "disk")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "subtask", "order", "disk")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "subtask", "swap", "disk")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "check", "1")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("1", "subtasks")
+	assertProgramResult(t, output, exiterr, success)
+
+	var subtasks []dstask.SubTask
+	assert.NoError(t, json.Unmarshal(output, &subtasks))
+	assert.Equal(t, []dstask.SubTask{
+		{Summary: "order disk", Resolved: true},
+		{Summary: "swap disk", Resolved: false},
+	}, subtasks)
+
+	_, _, success = program("1", "done")
+	assert.False(t, success, "incomplete subtasks")
+
+	_, _, success = program("1", "check", "3")
+	assert.False(t, success, "no such subtask")
+
+	output, exiterr, success = program("1", "check", "2")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Equal(t, "2/2", tasks[0].SubtaskProgress())
+
+	output, exiterr, success = program("1", "done")
+	assertProgramResult(t, output, exiterr, success)
+}
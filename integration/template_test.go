@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	config := filepath.Join(t.TempDir(), "dstaskrc")
+	err := os.WriteFile(config, []byte(`
+[templates]
+bar = '{{.ID}}: {{.Summary}}{{with .Tags}} [{{join . ","}}]{{end}}'
+`), 0o600)
+	assert.NoError(t, err)
+
+	defer setEnv("DSTASK_CONFIG", config)()
+
+	output, exiterr, success := program("add", "alpha", "+work", "+home", "project:web")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "bravo")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("next", "--template", "{{.ID}} {{.Summary}}")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, "1 alpha\n2 bravo\n", string(output))
+
+	output, exiterr, success = program("next", "--template=bar")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, "1: alpha [home,work]\n2: bravo\n", string(output))
+
+	output, exiterr, success = program("show-projects", "--template", "{{.Name}} {{.Tasks}}")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, "web 1\n", string(output))
+
+	output, exiterr, success = program("show-tags", "--template", "{{.Name}}")
+	assertProgramResult(t, output, exiterr, success)
+	assert.Equal(t, "home\nwork\n", string(output))
+
+	// a field that doesn't exist
+	_, _, success = program("next", "--template", "{{.Frobnicate}}")
+	assert.False(t, success)
+
+	_, _, success = program("next", "--template", "{{.Summary")
+	assert.False(t, success)
+}
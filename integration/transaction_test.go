@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gitLog returns the subjects of the commits of the repository, newest first.
+func gitLog(t *testing.T, repo string) []string {
+	t.Helper()
+
+	out, err := exec.Command("git", "-C", repo, "log", "--format=%s").Output()
+	assert.NoError(t, err)
+
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestMultipleIDsSingleCommit(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	for _, summary := range []string{"one", "two", "three"} {
+		output, exiterr, success := program("add", summary)
+		assertProgramResult(t, output, exiterr, success)
+	}
+
+	output, exiterr, success := program("modify", "1", "2", "3", "+extra")
+	assertProgramResult(t, output, exiterr, success)
+
+	subjects := gitLog(t, repo)
+	assert.Len(t, subjects, 4)
+	assert.Equal(t, "Modified 3 tasks", subjects[0])
+
+	out, err := exec.Command("git", "-C", repo, "log", "-1", "--format=%b").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "Modified 1: one\nModified 2: two\nModified 3: three", strings.TrimSpace(string(out)))
+
+	output, exiterr, success = program("done", "1")
+	assertProgramResult(t, output, exiterr, success)
+
+	subjects = gitLog(t, repo)
+	assert.Equal(t, "Resolved 1: one", subjects[0], "message of a single task")
+}
+
+func TestMultipleIDsRollback(t *testing.T) {
+	repo, cleanup := makeDstaskRepo(t)
+	defer cleanup()
+
+	program := testCmd(repo)
+
+	output, exiterr, success := program("add", "one")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("add", "two")
+	assertProgramResult(t, output, exiterr, success)
+
+	output, exiterr, success = program("subtask", "2", "first")
+	assertProgramResult(t, output, exiterr, success)
+
+	// two has an incomplete subtask, so one isn't resolved either
+	_, _, success = program("done", "1", "2")
+	assert.False(t, success)
+
+	assert.Len(t, gitLog(t, repo), 3, "nothing committed")
+
+	output, exiterr, success = program("next")
+	assertProgramResult(t, output, exiterr, success)
+
+	tasks := unmarshalTaskArray(t, output)
+	assert.Len(t, tasks, 2)
+
+	out, err := exec.Command("git", "-C", repo, "status", "--porcelain").Output()
+	assert.NoError(t, err)
+	assert.Empty(t, string(out), "nothing written")
+}
@@ -95,6 +95,11 @@ unset DSTASK_FAKE_PTY
 ./dstask template give me some things to do P1 +uniqueTag
 ./dstask show-templates +uniqueTag
 
+# test recurring tasks
+./dstask recur recur:daily check alerts +oncall
+./dstask show-recurring
+./dstask generate
+
 # test import
 ./dstask import-tw <etc/taskwarrior-export.json
 ./dstask next
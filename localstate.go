@@ -2,11 +2,13 @@ package dstask
 
 // this file represents the interface to the state specific to the PC dstask is
 // on is stored. This is very minimal at the moment -- just the current
-// context. It will probably remain that way.
+// context, or the name of it. It will probably remain that way.
 
 import (
+	"bytes"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -17,6 +19,9 @@ type State struct {
 	// Context is an implicit command line that changes the behavior or display
 	// of some commands.
 	Context Query
+	// ContextName is the active named context, stored in the repository. It
+	// takes the place of Context if set.
+	ContextName string
 }
 
 // Persistent DB of UUID -> ID to ensure that tasks have a persistent ID
@@ -27,96 +32,140 @@ type State struct {
 type IdsMap map[string]int
 
 // Save serialises State to disk as gob binary data.
-func (state State) Save(stateFilePath string) {
+func (state State) Save(stateFilePath string) error {
 	if err := os.MkdirAll(filepath.Dir(stateFilePath), os.ModePerm); err != nil {
-		ExitFail("Failed to create directories for %s: %s", stateFilePath, err)
+		return fmt.Errorf("failed to create directories for %s: %w", stateFilePath, err)
 	}
 
-	mustWriteGob(stateFilePath, &state)
+	return writeGob(stateFilePath, &state)
 }
 
 // LoadState reads the state file, if it exists. Otherwise a default State is returned.
-func LoadState(stateFilePath string) State {
+func LoadState(stateFilePath string) (State, error) {
+	state := State{}
+
 	if _, err := os.Stat(stateFilePath); os.IsNotExist(err) {
-		return State{}
+		return state, nil
 	}
 
-	state := State{}
-	mustReadGob(stateFilePath, &state)
+	err := readGob(stateFilePath, &state)
 
-	return state
+	return state, err
 }
 
 // SetContext sets a context on State, with some validation.
 func (state *State) SetContext(context Query) error {
-	if len(context.IDs) != 0 {
-		return errors.New("context cannot contain IDs")
-	}
-
-	if context.Text != "" {
-		return errors.New("context cannot contain text")
+	if err := validateContext(context); err != nil {
+		return err
 	}
 
 	state.Context = context
+	state.ContextName = ""
 
 	return nil
 }
 
-func mustWriteGob(filePath string, object any) {
-	file, err := os.Create(filePath)
+// UseContext makes a named context stored in the repository active.
+func (state *State) UseContext(repoPath, name string) error {
+	contexts, err := LoadContexts(repoPath)
 	if err != nil {
-		ExitFail("Failed to open %s for writing: ", filePath)
+		return err
 	}
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			ExitFail("Failed to close file: %v", err)
-		}
-	}()
+	if _, ok := contexts[name]; !ok {
+		return fmt.Errorf("no context named %s", name)
+	}
+
+	state.Context = Query{}
+	state.ContextName = name
 
-	encoder := gob.NewEncoder(file)
+	return nil
+}
 
-	err = encoder.Encode(object)
+// ActiveContext returns the named context if one is active, otherwise the
+// context set on State.
+func (state State) ActiveContext(repoPath string) (Query, error) {
+	if state.ContextName == "" {
+		return state.Context, nil
+	}
+
+	contexts, err := LoadContexts(repoPath)
 	if err != nil {
-		ExitFail("Failed to encode state gob: %s, %s", filePath, err)
+		return Query{}, err
+	}
+
+	context, ok := contexts[state.ContextName]
+	if !ok {
+		return Query{}, fmt.Errorf("active context %s no longer exists", state.ContextName)
+	}
+
+	return ParseContext(context)
+}
+
+func validateContext(context Query) error {
+	if len(context.IDs) != 0 {
+		return errors.New("context cannot contain IDs")
+	}
+
+	if context.Text != "" {
+		return errors.New("context cannot contain text")
+	}
+
+	return nil
+}
+
+func writeGob(filePath string, object any) error {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(object); err != nil {
+		return fmt.Errorf("failed to encode gob %s: %w", filePath, err)
 	}
+
+	if err := writeFileAtomic(filePath, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+
+	return nil
 }
 
-func mustReadGob(filePath string, object any) {
+func readGob(filePath string, object any) (err error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		ExitFail("Failed to open %s for reading: ", filePath)
+		return fmt.Errorf("failed to open %s for reading: %w", filePath, err)
 	}
 
 	defer func() {
-		if err := file.Close(); err != nil {
-			ExitFail("Failed to close file: %v", err)
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close %s: %w", filePath, closeErr)
 		}
 	}()
 
 	decoder := gob.NewDecoder(file)
 
-	err = decoder.Decode(object)
-	if err != nil {
-		ExitFail("Failed to parse state gob: %s, %s", filePath, err)
+	if err := decoder.Decode(object); err != nil {
+		return fmt.Errorf("failed to parse gob %s: %w", filePath, err)
 	}
+
+	return nil
 }
 
-func (ids *IdsMap) Save(idsFilePath string) {
+func (ids *IdsMap) Save(idsFilePath string) error {
 	if err := os.MkdirAll(filepath.Dir(idsFilePath), os.ModePerm); err != nil {
-		ExitFail("Failed to create directories for %s: %s", idsFilePath, err)
+		return fmt.Errorf("failed to create directories for %s: %w", idsFilePath, err)
 	}
 
-	mustWriteGob(idsFilePath, &ids)
+	return writeGob(idsFilePath, &ids)
 }
 
-func LoadIds(idsFilePath string) IdsMap {
+func LoadIds(idsFilePath string) (IdsMap, error) {
 	if _, err := os.Stat(idsFilePath); os.IsNotExist(err) {
-		return make(IdsMap)
+		return make(IdsMap), nil
 	}
 
 	ids := make(IdsMap, 1000)
-	mustReadGob(idsFilePath, &ids)
+	if err := readGob(idsFilePath, &ids); err != nil {
+		return nil, err
+	}
 
-	return ids
+	return ids, nil
 }
@@ -0,0 +1,85 @@
+package dstask
+
+// global options, accepted anywhere on the command line, eg. --color=never,
+// except after the git command, which passes its arguments to git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Options struct {
+	// auto, always or never, see COLOR
+	Color string
+	// see FORMATS
+	Format string
+	// text/template of each item of listings, or the name of one in the
+	// config file. See ParseOutputTemplate.
+	Template string
+}
+
+// CutOptions removes the global options from the command line, returning
+// the options given. Anything after the note keyword is a note, and anything
+// after the git command is for git, eg. dstask git log --format=%s.
+func CutOptions(args []string) ([]string, Options, error) {
+	var opts Options
+
+	remaining := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == NOTE_MODE_KEYWORD || (arg == CMD_GIT && len(remaining) == 0) {
+			remaining = append(remaining, args[i:]...)
+
+			break
+		}
+
+		// the template may be the next argument, being likely to have spaces
+		if arg == "--template" {
+			if i+1 == len(args) {
+				return nil, opts, errors.New("--template requires a template, eg. --template '{{.ID}} {{.Summary}}'")
+			}
+
+			i++
+			opts.Template = args[i]
+
+			continue
+		}
+
+		if text, ok := strings.CutPrefix(arg, "--template="); ok {
+			opts.Template = text
+
+			continue
+		}
+
+		if mode, ok := strings.CutPrefix(arg, "--color="); ok {
+			if !StrSliceContains(COLOR_MODES, mode) {
+				return nil, opts, fmt.Errorf("invalid --color: %s\nExpected always, never or auto", mode)
+			}
+
+			opts.Color = mode
+
+			continue
+		}
+
+		if format, ok := strings.CutPrefix(arg, "--format="); ok {
+			if !StrSliceContains(FORMATS, format) {
+				return nil, opts, fmt.Errorf("invalid --format: %s\nExpected one of %s", format, strings.Join(FORMATS, ", "))
+			}
+
+			opts.Format = format
+
+			continue
+		}
+
+		remaining = append(remaining, arg)
+	}
+
+	if opts.Template != "" && opts.Format != "" {
+		return nil, opts, errors.New("--template and --format cannot be used together")
+	}
+
+	return remaining, opts, nil
+}
@@ -0,0 +1,54 @@
+package dstask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCutOptions(t *testing.T) {
+	args, opts, err := CutOptions([]string{"next", "--color=never", "+work", "--format=csv"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"next", "+work"}, args)
+	assert.Equal(t, Options{Color: COLOR_NEVER, Format: FORMAT_CSV}, opts)
+
+	// notes are left alone
+	args, opts, err = CutOptions([]string{"add", "x", "/", "--color=always"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"add", "x", "/", "--color=always"}, args)
+	assert.Equal(t, Options{}, opts)
+
+	// git options are left alone
+	args, opts, err = CutOptions([]string{"--color=never", "git", "log", "--format=%s"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git", "log", "--format=%s"}, args)
+	assert.Equal(t, Options{Color: COLOR_NEVER}, opts)
+
+	args, opts, err = CutOptions([]string{"git", "log", "--color=always", "--template=x", "--template", "y"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git", "log", "--color=always", "--template=x", "--template", "y"}, args)
+	assert.Equal(t, Options{}, opts)
+
+	_, _, err = CutOptions([]string{"--color=sometimes"})
+	assert.Error(t, err)
+
+	_, _, err = CutOptions([]string{"--format=xml"})
+	assert.Error(t, err)
+}
+
+func TestCutOptionsTemplate(t *testing.T) {
+	args, opts, err := CutOptions([]string{"next", "--template", "{{.ID}} {{.Summary}}", "+work"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"next", "+work"}, args)
+	assert.Equal(t, Options{Template: "{{.ID}} {{.Summary}}"}, opts)
+
+	_, opts, err = CutOptions([]string{"next", "--template=bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", opts.Template)
+
+	_, _, err = CutOptions([]string{"next", "--template"})
+	assert.Error(t, err)
+
+	_, _, err = CutOptions([]string{"next", "--template=bar", "--format=json"})
+	assert.Error(t, err)
+}
@@ -16,11 +16,16 @@ import (
 )
 
 // Do runs the Github import if the user requested it.
-func Do(dstaskRepo string, cfg config.Config) error {
+func Do(conf dstask.Config, cfg config.Config) error {
 	if len(cfg.Github) == 0 {
 		return nil
 	}
 
+	store, err := conf.OpenStore()
+	if err != nil {
+		return err
+	}
+
 	for i, cfgGithub := range cfg.Github {
 		if cfgGithub.Token == "" {
 			logrus.Infof(
@@ -39,15 +44,13 @@ func Do(dstaskRepo string, cfg config.Config) error {
 			return err
 		}
 
-		err = gh.Run(dstaskRepo)
+		err = gh.Run(store)
 		if err != nil {
 			return err
 		}
 	}
 
-	dstask.MustGitCommit(dstaskRepo, "GitHub import")
-
-	return nil
+	return dstask.GitCommit(conf.Repo, "GitHub import")
 }
 
 // Github is a client to process multiple repos with a given template.
@@ -74,8 +77,8 @@ func NewClient(cfg config.Github) (*Github, error) {
 	return &g, nil
 }
 
-// Run processes the issues from all requested repositories.
-func (gh *Github) Run(dstaskRepo string) error {
+// Run processes the issues from all requested repositories into the store.
+func (gh *Github) Run(store dstask.Store) error {
 	for _, r := range gh.cfg.Repos {
 		iter, err := NewRepoIter(gh.cfg, r, gh.templates, gh.client)
 		if err != nil {
@@ -95,7 +98,7 @@ func (gh *Github) Run(dstaskRepo string) error {
 			}
 
 			for _, t := range tasks {
-				err = imp.ProcessTask(dstaskRepo, t)
+				err = imp.ProcessTask(store, t)
 				if err != nil {
 					return err
 				}
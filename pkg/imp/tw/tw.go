@@ -15,7 +15,7 @@ import (
 
 // Do imports a taskwarrior database.
 func Do(conf dstask.Config) error {
-	ts, err := dstask.LoadTaskSet(conf.Repo, conf.IDsFile, true)
+	ts, err := conf.LoadTaskSet(true)
 	if err != nil {
 		return err
 	}
@@ -43,15 +43,19 @@ func Do(conf dstask.Config) error {
 			Resolved:     twTask.GetResolvedTime(),
 			Due:          twTask.Due.Time,
 		})
-		if err != nil {
+		if errors.Is(err, dstask.ErrDuplicateTask) {
+			// already imported
+			continue
+		} else if err != nil {
 			return err
 		}
 	}
 
-	ts.SavePendingChanges()
-	dstask.MustGitCommit(conf.Repo, "Import from taskwarrior")
+	if err := ts.SavePendingChanges(); err != nil {
+		return err
+	}
 
-	return nil
+	return dstask.GitCommit(conf.Repo, "Import from taskwarrior")
 }
 
 type TwTime struct {
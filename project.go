@@ -0,0 +1,156 @@
+package dstask
+
+// projects are hierarchical, with segments separated by dots, eg.
+// infra.k8s.ingress. A project includes all of its descendants.
+//
+// Projects exist as long as they have tasks. Optionally, a record of metadata
+// is stored in the projects directory of the repository.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	PROJECT_SEPARATOR = "."
+	PROJECTS_DIR      = "projects"
+)
+
+// ProjectRecord is the metadata of a project, stored in the repository.
+type ProjectRecord struct {
+	Description string    `json:"description" yaml:",omitempty"`
+	Owner       string    `json:"owner" yaml:",omitempty"`
+	Deadline    time.Time `json:"deadline" yaml:",omitempty"`
+	// hidden from show-projects and completion. Includes descendants.
+	Archived bool `json:"archived" yaml:",omitempty"`
+}
+
+// LoadProjectRecords reads all project records in the repository.
+func LoadProjectRecords(repoPath string) (map[string]ProjectRecord, error) {
+	records := make(map[string]ProjectRecord)
+
+	files, err := os.ReadDir(filepath.Join(repoPath, PROJECTS_DIR))
+	if os.IsNotExist(err) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		name, found := strings.CutSuffix(file.Name(), ".yml")
+		if !found || file.IsDir() {
+			continue
+		}
+
+		record, err := LoadProjectRecord(repoPath, name)
+		if err != nil {
+			return nil, err
+		}
+
+		records[name] = record
+	}
+
+	return records, nil
+}
+
+// LoadProjectRecord reads the record of a project. A project without a
+// record has an empty one.
+func LoadProjectRecord(repoPath, name string) (ProjectRecord, error) {
+	var record ProjectRecord
+
+	data, err := os.ReadFile(filepath.Join(repoPath, PROJECTS_DIR, name+".yml"))
+	if os.IsNotExist(err) {
+		return record, nil
+	} else if err != nil {
+		return record, err
+	}
+
+	if err := yaml.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to parse project %s: %w", name, err)
+	}
+
+	return record, nil
+}
+
+// SaveProjectRecord writes the record of a project. The change is not
+// committed.
+func SaveProjectRecord(repoPath, name string, record ProjectRecord) error {
+	if name == "" {
+		return errors.New("project name required")
+	}
+
+	if err := validateProject(name); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&record)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(repoPath, PROJECTS_DIR)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, name+".yml"), data, 0o600)
+}
+
+// isArchived returns true if the project or an ancestor is archived.
+func isArchived(records map[string]ProjectRecord, project string) bool {
+	for _, name := range append([]string{project}, ProjectAncestors(project)...) {
+		if records[name].Archived {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ProjectContains returns true if the project is the given ancestor, or a
+// descendant of it.
+func ProjectContains(ancestor, project string) bool {
+	return project == ancestor || strings.HasPrefix(project, ancestor+PROJECT_SEPARATOR)
+}
+
+// ProjectAncestors returns the ancestors of a project, closest first.
+func ProjectAncestors(project string) []string {
+	var ancestors []string
+
+	for i := strings.LastIndex(project, PROJECT_SEPARATOR); i > 0; i = strings.LastIndex(project, PROJECT_SEPARATOR) {
+		project = project[:i]
+		ancestors = append(ancestors, project)
+	}
+
+	return ancestors
+}
+
+// ProjectDepth returns the number of ancestors of a project.
+func ProjectDepth(project string) int {
+	return strings.Count(project, PROJECT_SEPARATOR)
+}
+
+// compareProjects orders projects depth first, so that descendants directly
+// follow their ancestor.
+func compareProjects(a, b string) int {
+	return slices.Compare(strings.Split(a, PROJECT_SEPARATOR), strings.Split(b, PROJECT_SEPARATOR))
+}
+
+func validateProject(project string) error {
+	if project == "" {
+		return nil
+	}
+
+	if slices.Contains(strings.Split(project, PROJECT_SEPARATOR), "") {
+		return errors.New("invalid project, segments cannot be empty")
+	}
+
+	return nil
+}
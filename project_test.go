@@ -0,0 +1,109 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectContains(t *testing.T) {
+	assert.True(t, ProjectContains("infra", "infra"))
+	assert.True(t, ProjectContains("infra", "infra.k8s.ingress"))
+	assert.False(t, ProjectContains("infra", "infrastructure"))
+	assert.False(t, ProjectContains("infra.k8s", "infra"))
+
+	assert.Equal(t, []string{"infra.k8s", "infra"}, ProjectAncestors("infra.k8s.ingress"))
+	assert.Empty(t, ProjectAncestors("infra"))
+}
+
+func TestMatchesFilterProjectDescendants(t *testing.T) {
+	task := Task{Project: "infra.k8s.ingress"}
+
+	assert.True(t, task.MatchesFilter(mustParseQuery(t, "project:infra")))
+	assert.True(t, task.MatchesFilter(mustParseQuery(t, "project:infra.k8s")))
+	assert.False(t, task.MatchesFilter(mustParseQuery(t, "project:infra.db")))
+	assert.False(t, task.MatchesFilter(mustParseQuery(t, "-project:infra")))
+	assert.True(t, task.MatchesFilter(mustParseQuery(t, "-project:infra.db")))
+}
+
+func TestGetProjectsRollup(t *testing.T) {
+	ts := newTestTaskSet()
+
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "a", Project: "infra.k8s.ingress", Priority: PRIORITY_HIGH})
+	mustLoadTask(t, ts, Task{Status: STATUS_RESOLVED, Summary: "b", Project: "infra.k8s", Priority: PRIORITY_CRITICAL})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "c", Project: "infra-old", Priority: PRIORITY_NORMAL})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "d", Project: "infra.db", Priority: PRIORITY_LOW})
+
+	var names []string
+	for _, p := range ts.GetProjects() {
+		names = append(names, p.Name)
+	}
+
+	// depth first, descendants follow their ancestor
+	assert.Equal(t, []string{"infra", "infra.db", "infra.k8s", "infra.k8s.ingress", "infra-old"}, names)
+
+	infra := ts.GetProjects()[0]
+	assert.Equal(t, "", infra.Parent)
+	assert.Equal(t, 3, infra.Tasks)
+	assert.Equal(t, 1, infra.TasksResolved)
+	// resolved tasks do not count towards priority
+	assert.Equal(t, PRIORITY_HIGH, infra.Priority)
+
+	k8s := ts.GetProjects()[2]
+	assert.Equal(t, "infra", k8s.Parent)
+	assert.Equal(t, 2, k8s.Tasks)
+}
+
+func TestValidateProject(t *testing.T) {
+	assert.NoError(t, validateProject("infra.k8s"))
+	assert.Error(t, validateProject("infra..k8s"))
+	assert.Error(t, validateProject(".infra"))
+}
+
+func TestProjectRecords(t *testing.T) {
+	repo := t.TempDir()
+
+	records, err := LoadProjectRecords(repo)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	deadline := time.Date(2026, 12, 1, 0, 0, 0, 0, time.Local)
+
+	assert.NoError(t, SaveProjectRecord(repo, "infra.k8s", ProjectRecord{Owner: "alice", Deadline: deadline}))
+	assert.NoError(t, SaveProjectRecord(repo, "infra", ProjectRecord{Archived: true}))
+	assert.Error(t, SaveProjectRecord(repo, "infra..k8s", ProjectRecord{}))
+
+	records, err = LoadProjectRecords(repo)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "alice", records["infra.k8s"].Owner)
+	assert.True(t, records["infra.k8s"].Deadline.Equal(deadline))
+
+	// archiving a project archives its descendants
+	assert.True(t, isArchived(records, "infra.k8s.ingress"))
+	assert.False(t, isArchived(records, "web"))
+
+	record, err := LoadProjectRecord(repo, "web")
+	assert.NoError(t, err)
+	assert.Equal(t, ProjectRecord{}, record)
+}
+
+func TestProjectDeadline(t *testing.T) {
+	ts := newTestTaskSet()
+	ts.projectRecords = map[string]ProjectRecord{
+		"infra":     {Deadline: time.Date(2026, 12, 1, 0, 0, 0, 0, time.Local)},
+		"infra.k8s": {Deadline: time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)},
+		"infra.db":  {Owner: "bob"},
+	}
+
+	project, deadline := ts.ProjectDeadline("infra.k8s.ingress")
+	assert.Equal(t, "infra.k8s", project)
+	assert.Equal(t, time.November, deadline.Month())
+
+	project, _ = ts.ProjectDeadline("infra.db")
+	assert.Equal(t, "infra", project, "inherited from ancestor")
+
+	_, deadline = ts.ProjectDeadline("web")
+	assert.True(t, deadline.IsZero())
+}
@@ -3,8 +3,10 @@ package dstask
 // main task data structures
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,18 +15,30 @@ import (
 // when referring to tasks by ID, NON_RESOLVED_STATUSES must be loaded exclusively --
 // even if the filter is set to show issues that have only some statuses.
 type Query struct {
-	Cmd           string
-	IDs           []int
-	Tags          []string
-	AntiTags      []string
-	Project       string
-	AntiProjects  []string
-	Due           time.Time
-	DateFilter    string
-	Priority      string
-	Template      int
-	Text          string
-	IgnoreContext bool
+	Cmd              string
+	IDs              []int
+	Tags             []string
+	AntiTags         []string
+	Project          string
+	AntiProjects     []string
+	Due              time.Time
+	DateFilter       string    // after, before, on, in, within or overdue
+	DueBefore        time.Time // upper bound of a due.after: due.before: range
+	Priority         string
+	PriorityFilter   string // <, <=, > or >=. P0 is the highest priority
+	DelegatedTo      string
+	Dependencies     []int // IDs of tasks to add as dependencies
+	AntiDependencies []int // IDs of tasks to remove as dependencies
+	Blocked          bool  // only tasks blocked by an unresolved dependency
+	Unblocked        bool
+	Template         int
+	Recur            string // canonical schedule, see ParseSchedule
+	Text             string
+	Searches         []Search  // summary:, notes: and /regex/ search terms
+	Sort             []SortKey // order of listed tasks, see ParseSortKeys
+	IgnoreContext    bool
+	// boolean filter expression, see parseFilterExpr
+	Expr *Expr
 	// any words after the note operator: /
 	Note string
 }
@@ -53,23 +67,71 @@ func (query Query) String() string {
 		args = append(args, "-project:"+project)
 	}
 
-	if !query.Due.IsZero() {
+	if query.DateFilter == "overdue" {
+		args = append(args, "due:overdue")
+	} else if !query.Due.IsZero() {
 		dueArg := "due"
 		if query.DateFilter != "" {
 			dueArg += "." + query.DateFilter
 		}
-		dueArg += ":" + query.Due.Format("2006-01-02")
-		args = append(args, dueArg)
+		args = append(args, dueArg+":"+formatDueArg(query.Due))
 	}
 
-	if query.Priority != "" {
+	if !query.DueBefore.IsZero() {
+		args = append(args, "due.before:"+formatDueArg(query.DueBefore))
+	}
+
+	if query.PriorityFilter != "" {
+		args = append(args, "priority"+query.PriorityFilter+query.Priority)
+	} else if query.Priority != "" {
 		args = append(args, query.Priority)
 	}
 
+	if query.DelegatedTo != "" {
+		args = append(args, "delegated:"+query.DelegatedTo)
+	}
+
+	for _, id := range query.Dependencies {
+		args = append(args, fmt.Sprintf("dep:%v", id))
+	}
+
+	for _, id := range query.AntiDependencies {
+		args = append(args, fmt.Sprintf("-dep:%v", id))
+	}
+
+	if query.Blocked {
+		args = append(args, ":blocked")
+	}
+
+	if query.Unblocked {
+		args = append(args, ":unblocked")
+	}
+
 	if query.Template > 0 {
 		args = append(args, fmt.Sprintf("template:%v", query.Template))
 	}
 
+	if query.Recur != "" {
+		args = append(args, "recur:"+query.Recur)
+	}
+
+	if query.Expr != nil {
+		args = append(args, query.Expr.String())
+	}
+
+	for _, search := range query.Searches {
+		args = append(args, search.String())
+	}
+
+	if len(query.Sort) > 0 {
+		keys := make([]string, 0, len(query.Sort))
+		for _, key := range query.Sort {
+			keys = append(keys, key.String())
+		}
+
+		args = append(args, "sort:"+strings.Join(keys, ","))
+	}
+
 	if query.Text != "" {
 		args = append(args, "\""+query.Text+"\"")
 	}
@@ -77,6 +139,14 @@ func (query Query) String() string {
 	return strings.Join(args, " ")
 }
 
+func formatDueArg(due time.Time) string {
+	if hasTimeOfDay(due) {
+		return due.Format("2006-01-02T15:04")
+	}
+
+	return due.Format("2006-01-02")
+}
+
 func (query Query) PrintContextDescription() {
 	var envVarNotification string
 	if os.Getenv("DSTASK_CONTEXT") != "" {
@@ -84,12 +154,13 @@ func (query Query) PrintContextDescription() {
 	}
 
 	if query.String() != "" {
-		fmt.Printf("\033[33mActive context%s: %s\033[0m\n", envVarNotification, query)
+		printPainted(FG_CONTEXT, "Active context%s: %s", envVarNotification, query)
 	}
 }
 
 // HasOperators returns true if the query has positive or negative projects/tags,
-// dueDate, priorities or template.
+// dueDate, priorities, delegate, dependencies, template, schedule, search
+// terms or a filter expression.
 func (query Query) HasOperators() bool {
 	return (len(query.Tags) > 0 ||
 		len(query.AntiTags) > 0 ||
@@ -97,12 +168,61 @@ func (query Query) HasOperators() bool {
 		len(query.AntiProjects) > 0 ||
 		query.Due != time.Time{} ||
 		query.DateFilter != "" ||
+		!query.DueBefore.IsZero() ||
 		query.Priority != "" ||
-		query.Template > 0)
+		query.DelegatedTo != "" ||
+		len(query.Dependencies) > 0 ||
+		len(query.AntiDependencies) > 0 ||
+		query.Blocked ||
+		query.Unblocked ||
+		query.Template > 0 ||
+		query.Recur != "" ||
+		len(query.Searches) > 0 ||
+		query.Expr != nil)
 }
 
 // ParseQuery parses the raw command line typed by the user.
-func ParseQuery(args ...string) Query {
+func ParseQuery(args ...string) (Query, error) {
+	var expr *Expr
+
+	// words of content commands are not an expression, eg. dstask add call
+	// bob (not urgent)
+	if !StrSliceContains(CONTENT_CMDS, queryCmd(args)) {
+		var err error
+
+		args, expr, err = parseFilterExpr(args)
+		if err != nil {
+			return Query{}, err
+		}
+	}
+
+	query, err := parseArgs(args)
+	if err != nil {
+		return Query{}, err
+	}
+
+	query.Expr = expr
+
+	return query, nil
+}
+
+// queryCmd returns the command of a command line, as parseArgs finds it.
+func queryCmd(args []string) string {
+	for _, item := range args {
+		if item == NOTE_MODE_KEYWORD {
+			break
+		}
+
+		if lcItem := strings.ToLower(item); StrSliceContains(ALL_CMDS, lcItem) {
+			return lcItem
+		}
+	}
+
+	return ""
+}
+
+// parseArgs parses a command line without a filter expression.
+func parseArgs(args []string) (Query, error) {
 	var cmd string
 
 	var ids []int
@@ -119,10 +239,30 @@ func ParseQuery(args ...string) Query {
 
 	var dateFilterType string
 
+	var dueBefore time.Time
+
 	var priority string
 
+	var priorityFilter string
+
+	var delegatedTo string
+
+	var dependencies []int
+
+	var antiDependencies []int
+
+	var blocked bool
+
+	var unblocked bool
+
 	var template int
 
+	var recur string
+
+	var searches []Search
+
+	var sortKeys []SortKey
+
 	var words []string
 
 	var notesModeActivated bool
@@ -134,8 +274,6 @@ func ParseQuery(args ...string) Query {
 	// something other than an ID has been parsed -- accept no more IDs
 	var IDsExhausted bool
 
-	var dueDateSet bool
-
 	for _, item := range args {
 		lcItem := strings.ToLower(item)
 
@@ -171,19 +309,81 @@ func ParseQuery(args ...string) Query {
 		} else if strings.HasPrefix(lcItem, "-project:") {
 			antiProjects = append(antiProjects, lcItem[9:])
 		} else if strings.HasPrefix(lcItem, "due.") || strings.HasPrefix(lcItem, "due:") {
-			if dueDateSet {
-				ExitFail("Query should only have one due date")
+			filter, date, err := ParseDueDateArg(lcItem)
+			if err != nil {
+				return Query{}, err
+			}
+
+			switch {
+			case dueDate.IsZero():
+				dateFilterType, dueDate = filter, date
+			// a second due date is only valid as the other end of a range
+			case dueBefore.IsZero() && dateFilterType == "after" && filter == "before":
+				dueBefore = date
+			case dueBefore.IsZero() && dateFilterType == "before" && filter == "after":
+				dateFilterType, dueDate, dueBefore = filter, date, dueDate
+			default:
+				return Query{}, errors.New("query should only have one due date, or a due.after: and due.before: range")
 			}
-			dateFilterType, dueDate = ParseDueDateArg(lcItem)
-			dueDateSet = true
 		} else if strings.HasPrefix(lcItem, "template:") {
 			if s, err := strconv.ParseInt(lcItem[9:], 10, 64); err == nil {
 				template = int(s)
 			}
+		} else if delegatedTo == "" && strings.HasPrefix(lcItem, "delegated:") {
+			// names are case-preserving
+			delegatedTo = item[10:]
+		} else if strings.HasPrefix(lcItem, "dep:") {
+			id, err := parseDependencyID(lcItem[4:])
+			if err != nil {
+				return Query{}, err
+			}
+			dependencies = append(dependencies, id)
+		} else if strings.HasPrefix(lcItem, "-dep:") {
+			id, err := parseDependencyID(lcItem[5:])
+			if err != nil {
+				return Query{}, err
+			}
+			antiDependencies = append(antiDependencies, id)
+		} else if lcItem == ":blocked" {
+			blocked = true
+		} else if lcItem == ":unblocked" {
+			unblocked = true
+		} else if strings.HasPrefix(lcItem, "recur:") {
+			schedule, err := ParseSchedule(lcItem[6:])
+			if err != nil {
+				return Query{}, err
+			}
+			recur = schedule.String()
+		} else if sortKeys == nil && strings.HasPrefix(lcItem, "sort:") {
+			keys, err := ParseSortKeys(strings.Split(lcItem[5:], ","))
+			if err != nil {
+				return Query{}, fmt.Errorf("%w\nExpected eg. sort:due,-priority,project", err)
+			}
+			sortKeys = keys
+		} else if strings.HasPrefix(lcItem, SEARCH_SUMMARY+":") || strings.HasPrefix(lcItem, SEARCH_NOTES+":") {
+			field, pattern, _ := strings.Cut(item, ":")
+			// patterns are case-preserving, for regular expressions
+			search, err := ParseSearch(strings.ToLower(field), pattern)
+			if err != nil {
+				return Query{}, err
+			}
+			searches = append(searches, search)
+		} else if isRegexpPattern(item) && !StrSliceContains(CONTENT_CMDS, cmd) {
+			search, err := ParseSearch("", item)
+			if err != nil {
+				return Query{}, err
+			}
+			searches = append(searches, search)
 		} else if len(item) > 1 && lcItem[0:1] == "+" {
 			tags = append(tags, lcItem[1:])
 		} else if len(item) > 1 && lcItem[0:1] == "-" {
 			antiTags = append(antiTags, lcItem[1:])
+		} else if priority == "" && isPriorityComparison(lcItem) {
+			op, p, err := parsePriorityComparison(lcItem)
+			if err != nil {
+				return Query{}, err
+			}
+			priorityFilter, priority = op, p
 		} else if priority == "" && IsValidPriority(item) {
 			priority = item
 		} else {
@@ -194,24 +394,121 @@ func ParseQuery(args ...string) Query {
 	}
 
 	return Query{
-		Cmd:           cmd,
-		IDs:           ids,
-		Tags:          tags,
-		AntiTags:      antiTags,
-		Project:       project,
-		AntiProjects:  antiProjects,
-		DateFilter:    dateFilterType,
-		Due:           dueDate,
-		Priority:      priority,
-		Template:      template,
-		Text:          strings.Join(words, " "),
-		Note:          strings.Join(notes, " "),
-		IgnoreContext: ignoreContext,
+		Cmd:              cmd,
+		IDs:              ids,
+		Tags:             tags,
+		AntiTags:         antiTags,
+		Project:          project,
+		AntiProjects:     antiProjects,
+		DateFilter:       dateFilterType,
+		Due:              dueDate,
+		DueBefore:        dueBefore,
+		Priority:         priority,
+		PriorityFilter:   priorityFilter,
+		DelegatedTo:      delegatedTo,
+		Dependencies:     dependencies,
+		AntiDependencies: antiDependencies,
+		Blocked:          blocked,
+		Unblocked:        unblocked,
+		Template:         template,
+		Recur:            recur,
+		Text:             strings.Join(words, " "),
+		Searches:         searches,
+		Sort:             sortKeys,
+		Note:             strings.Join(notes, " "),
+		IgnoreContext:    ignoreContext,
+	}, nil
+}
+
+// checkSettable returns an error if the query has operators that can only
+// filter tasks, rather than set their attributes.
+func (query Query) checkSettable() error {
+	if query.DateFilter != "" && query.DateFilter != "in" && query.DateFilter != "on" {
+		return fmt.Errorf("cannot use date filter with %s command", query.Cmd)
+	}
+
+	if query.PriorityFilter != "" {
+		return fmt.Errorf("cannot use priority comparison with %s command", query.Cmd)
+	}
+
+	if query.DelegatedTo != "" {
+		return fmt.Errorf("cannot use delegated: with %s command, see dstask help delegate", query.Cmd)
+	}
+
+	if query.Expr != nil {
+		return fmt.Errorf("cannot use filter expression with %s command", query.Cmd)
+	}
+
+	if len(query.Searches) > 0 {
+		return fmt.Errorf("cannot use search terms with %s command", query.Cmd)
+	}
+
+	if len(query.Sort) > 0 {
+		return fmt.Errorf("cannot use sort order with %s command", query.Cmd)
+	}
+
+	return nil
+}
+
+// settable returns the query without the operators rejected by
+// checkSettable, so that a context can be applied to a new task. The context
+// still filters the tasks listed.
+func (query Query) settable() Query {
+	if query.DateFilter != "" && query.DateFilter != "in" && query.DateFilter != "on" {
+		query.Due = time.Time{}
+		query.DateFilter = ""
+		query.DueBefore = time.Time{}
+	}
+
+	if query.PriorityFilter != "" {
+		query.Priority = ""
+		query.PriorityFilter = ""
+	}
+
+	query.DelegatedTo = ""
+	query.Expr = nil
+	query.Searches = nil
+	query.Sort = nil
+
+	return query
+}
+
+func isPriorityComparison(lcItem string) bool {
+	return strings.HasPrefix(lcItem, "priority<") || strings.HasPrefix(lcItem, "priority>") ||
+		strings.HasPrefix(lcItem, "priority=")
+}
+
+// parsePriorityComparison parses eg. priority<=P1 into its operator and
+// priority. priority=P1 is the same as P1.
+func parsePriorityComparison(lcItem string) (string, string, error) {
+	comparison := lcItem[len("priority"):]
+	priority := strings.TrimLeft(comparison, "<>=")
+	op := comparison[:len(comparison)-len(priority)]
+	priority = strings.ToUpper(priority)
+
+	if !StrSliceContains([]string{"<", "<=", ">", ">=", "="}, op) || !IsValidPriority(priority) {
+		return "", "", fmt.Errorf("invalid priority comparison: %s\nExpected eg. priority<=P1", lcItem)
+	}
+
+	if op == "=" {
+		op = ""
 	}
+
+	return op, priority, nil
+}
+
+func parseDependencyID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid dependency: %s\nExpected the ID of a task, eg. dep:12", s)
+	}
+
+	return id, nil
 }
 
 // Merge applies a context to a new task. Returns new Query, does not mutate.
-func (query *Query) Merge(q2 Query) Query {
+// Returns an error if the context conflicts with the query.
+func (query *Query) Merge(q2 Query) (Query, error) {
 	// dereference to make a copy of this query
 	q := *query
 
@@ -228,29 +525,63 @@ func (query *Query) Merge(q2 Query) Query {
 	}
 
 	if q2.Project != "" {
-		if q.Project != "" && q.Project != q2.Project {
-			ExitFail("Could not apply q2, project conflict")
-		} else {
+		if q.Project != "" && !ProjectContains(q2.Project, q.Project) {
+			if !ProjectContains(q.Project, q2.Project) {
+				return Query{}, errors.New("could not apply context, project conflict")
+			}
+
+			// the more specific project
+			q.Project = q2.Project
+		} else if q.Project == "" {
 			q.Project = q2.Project
 		}
 	}
 
-	if !q2.Due.IsZero() {
-		if !q.Due.IsZero() && q.Due != q2.Due {
-			ExitFail("Could not apply q2, date filter conflict")
+	if !q2.Due.IsZero() || q2.DateFilter != "" {
+		if (!q.Due.IsZero() || q.DateFilter != "") &&
+			(q.Due != q2.Due || q.DateFilter != q2.DateFilter || q.DueBefore != q2.DueBefore) {
+			return Query{}, errors.New("could not apply context, date filter conflict")
 		} else {
 			q.Due = q2.Due
 			q.DateFilter = q2.DateFilter
+			q.DueBefore = q2.DueBefore
 		}
 	}
 
 	if q2.Priority != "" {
 		if q.Priority != "" {
-			ExitFail("Could not apply q2, priority conflict")
+			return Query{}, errors.New("could not apply context, priority conflict")
 		} else {
 			q.Priority = q2.Priority
+			q.PriorityFilter = q2.PriorityFilter
+		}
+	}
+
+	q.Searches = append(slices.Clone(q.Searches), q2.Searches...)
+
+	// the sort order of the query wins over that of the context
+	if len(q.Sort) == 0 {
+		q.Sort = q2.Sort
+	}
+
+	if q2.Expr != nil {
+		if q.Expr != nil {
+			q.Expr = &Expr{Op: EXPR_AND, Args: []*Expr{q.Expr, q2.Expr}}
+		} else {
+			q.Expr = q2.Expr
+		}
+	}
+
+	q.Blocked = q.Blocked || q2.Blocked
+	q.Unblocked = q.Unblocked || q2.Unblocked
+
+	if q2.DelegatedTo != "" {
+		if q.DelegatedTo != "" && !strings.EqualFold(q.DelegatedTo, q2.DelegatedTo) {
+			return Query{}, errors.New("could not apply context, delegate conflict")
+		} else {
+			q.DelegatedTo = q2.DelegatedTo
 		}
 	}
 
-	return q
+	return q, nil
 }
@@ -0,0 +1,337 @@
+package dstask
+
+// recurring tasks are stored with STATUS_RECURRING and a schedule. They are
+// never worked on directly; instead, pending instances are materialised from
+// them by the generate command.
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FREQ_DAILY   = "daily"
+	FREQ_WEEKLY  = "weekly"
+	FREQ_MONTHLY = "monthly"
+)
+
+// Schedule is a simplified RRULE. It is stored on the recurring task in its
+// canonical string form, for example:
+//
+//	freq=weekly;byday=mo,th
+//	freq=monthly;bymonthday=15
+//	freq=daily;interval=3;from=completion
+type Schedule struct {
+	Freq string
+	// every Interval days/weeks/months
+	Interval int
+	// weekly only. Empty means the weekday the recurring task was created on.
+	ByDay []time.Weekday
+	// monthly only. Zero means the day of month the recurring task was
+	// created on.
+	ByMonthDay int
+	// the next instance is scheduled relative to the completion of the
+	// previous one, rather than the calendar
+	FromCompletion bool
+}
+
+var rruleWeekdays = []string{"su", "mo", "tu", "we", "th", "fr", "sa"}
+
+// ParseSchedule parses an RRULE-style schedule. The shorthands daily, weekly
+// and monthly are accepted too.
+func ParseSchedule(rule string) (Schedule, error) {
+	s := Schedule{Interval: 1}
+	rule = strings.ToLower(strings.TrimSpace(rule))
+
+	if rule == "" {
+		return Schedule{}, fmt.Errorf("empty schedule")
+	}
+
+	for _, part := range strings.Split(rule, ";") {
+		key, val, found := strings.Cut(part, "=")
+
+		if !found {
+			// shorthand, eg. weekly
+			key, val = "freq", part
+		}
+
+		switch key {
+		case "freq":
+			if val != FREQ_DAILY && val != FREQ_WEEKLY && val != FREQ_MONTHLY {
+				return Schedule{}, fmt.Errorf("invalid schedule frequency: %s", val)
+			}
+
+			s.Freq = val
+		case "interval":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return Schedule{}, fmt.Errorf("invalid schedule interval: %s", val)
+			}
+
+			s.Interval = n
+		case "byday":
+			for _, day := range strings.Split(val, ",") {
+				i := slices.Index(rruleWeekdays, day)
+				if i < 0 {
+					return Schedule{}, fmt.Errorf("invalid schedule weekday: %s", day)
+				}
+
+				if !slices.Contains(s.ByDay, time.Weekday(i)) {
+					s.ByDay = append(s.ByDay, time.Weekday(i))
+				}
+			}
+
+			slices.Sort(s.ByDay)
+		case "bymonthday":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 31 {
+				return Schedule{}, fmt.Errorf("invalid schedule day of month: %s", val)
+			}
+
+			s.ByMonthDay = n
+		case "from":
+			if val != "completion" {
+				return Schedule{}, fmt.Errorf("invalid schedule anchor: %s", val)
+			}
+
+			s.FromCompletion = true
+		default:
+			return Schedule{}, fmt.Errorf("invalid schedule rule: %s", part)
+		}
+	}
+
+	if s.Freq == "" {
+		return Schedule{}, fmt.Errorf("schedule has no frequency: %s", rule)
+	}
+
+	if len(s.ByDay) > 0 && s.Freq != FREQ_WEEKLY {
+		return Schedule{}, fmt.Errorf("byday is only valid for weekly schedules")
+	}
+
+	if s.ByMonthDay > 0 && s.Freq != FREQ_MONTHLY {
+		return Schedule{}, fmt.Errorf("bymonthday is only valid for monthly schedules")
+	}
+
+	if s.FromCompletion && (len(s.ByDay) > 0 || s.ByMonthDay > 0) {
+		return Schedule{}, fmt.Errorf("calendar rules cannot be combined with from=completion")
+	}
+
+	return s, nil
+}
+
+// String returns the canonical form of the schedule.
+func (s Schedule) String() string {
+	parts := []string{"freq=" + s.Freq}
+
+	if s.Interval > 1 {
+		parts = append(parts, "interval="+strconv.Itoa(s.Interval))
+	}
+
+	if len(s.ByDay) > 0 {
+		days := make([]string, 0, len(s.ByDay))
+		for _, day := range s.ByDay {
+			days = append(days, rruleWeekdays[day])
+		}
+
+		parts = append(parts, "byday="+strings.Join(days, ","))
+	}
+
+	if s.ByMonthDay > 0 {
+		parts = append(parts, "bymonthday="+strconv.Itoa(s.ByMonthDay))
+	}
+
+	if s.FromCompletion {
+		parts = append(parts, "from=completion")
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// anchored fills in the weekday or day of month of calendar schedules that
+// do not specify one, from the given time.
+func (s Schedule) anchored(t time.Time) Schedule {
+	if s.Freq == FREQ_WEEKLY && len(s.ByDay) == 0 {
+		s.ByDay = []time.Weekday{t.Weekday()}
+	}
+
+	if s.Freq == FREQ_MONTHLY && s.ByMonthDay == 0 {
+		s.ByMonthDay = t.Day()
+	}
+
+	return s
+}
+
+// First returns the first occurrence on or after the given day.
+func (s Schedule) First(start time.Time) time.Time {
+	start = startOfDay(start)
+	s = s.anchored(start)
+
+	switch s.Freq {
+	case FREQ_WEEKLY:
+		for d := range 7 {
+			if day := start.AddDate(0, 0, d); slices.Contains(s.ByDay, day.Weekday()) {
+				return day
+			}
+		}
+	case FREQ_MONTHLY:
+		if start.Day() <= min(s.ByMonthDay, daysIn(start.Year(), start.Month())) {
+			return s.monthDay(start, 0)
+		}
+
+		return s.monthDay(start, 1)
+	}
+
+	return start
+}
+
+// Next returns the first occurrence strictly after the given day. Occurrences
+// are whole days (midnight, local time).
+func (s Schedule) Next(after time.Time) time.Time {
+	after = startOfDay(after)
+	s = s.anchored(after)
+
+	switch s.Freq {
+	case FREQ_WEEKLY:
+		// remaining days in this week, weeks starting on Monday
+		daysIntoWeek := (int(after.Weekday()) + 6) % 7
+		for d := daysIntoWeek + 1; d < 7; d++ {
+			if slices.Contains(s.ByDay, time.Weekday((d+1)%7)) {
+				return after.AddDate(0, 0, d-daysIntoWeek)
+			}
+		}
+
+		// first matching day of the next scheduled week
+		monday := after.AddDate(0, 0, -daysIntoWeek+7*s.Interval)
+		for d := range 7 {
+			if slices.Contains(s.ByDay, time.Weekday((d+1)%7)) {
+				return monday.AddDate(0, 0, d)
+			}
+		}
+	case FREQ_MONTHLY:
+		if after.Day() < min(s.ByMonthDay, daysIn(after.Year(), after.Month())) {
+			return s.monthDay(after, 0)
+		}
+
+		return s.monthDay(after, s.Interval)
+	}
+
+	return after.AddDate(0, 0, s.Interval)
+}
+
+// monthDay returns ByMonthDay of the month n months after t. The day is
+// clamped to the length of the month, so bymonthday=31 is the last day of
+// every month.
+func (s Schedule) monthDay(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.Local)
+
+	return first.AddDate(0, 0, min(s.ByMonthDay, daysIn(first.Year(), first.Month()))-1)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+}
+
+// GenerateRecurring materialises pending instances of recurring tasks that
+// are due on or before the given day. At most one open instance of each
+// recurring task exists at a time; missed occurrences are skipped rather than
+// piled up. Resolved tasks must be loaded for from=completion schedules to
+// work. Returns the newly created tasks.
+func (ts *TaskSet) GenerateRecurring(now time.Time) ([]Task, error) {
+	var created []Task
+
+	today := startOfDay(now)
+
+	for _, r := range ts.AllTasks() {
+		if r.Status != STATUS_RECURRING {
+			continue
+		}
+
+		schedule, err := ParseSchedule(r.Recur)
+		if err != nil {
+			return nil, fmt.Errorf("%w, task %s", err, r.UUID)
+		}
+
+		// from=completion schedules recur an interval after completion,
+		// whatever the day of creation
+		if !schedule.FromCompletion {
+			schedule = schedule.anchored(r.Created)
+		}
+
+		var open bool
+
+		var lastResolved time.Time
+
+		for _, t := range ts.tasks {
+			if t.Parent != r.UUID {
+				continue
+			}
+
+			if t.Status == STATUS_RESOLVED {
+				if t.Resolved.After(lastResolved) {
+					lastResolved = t.Resolved
+				}
+			} else {
+				open = true
+			}
+		}
+
+		if open {
+			continue
+		}
+
+		var occurrence time.Time
+
+		if schedule.FromCompletion {
+			if lastResolved.IsZero() {
+				// first instance is due immediately
+				occurrence = today
+			} else {
+				occurrence = schedule.Next(lastResolved)
+			}
+		} else {
+			if r.LastRecurrence.IsZero() {
+				occurrence = schedule.First(r.Created)
+			} else {
+				occurrence = schedule.Next(r.LastRecurrence)
+			}
+
+			// skip missed occurrences
+			for next := schedule.Next(occurrence); !next.After(today); next = schedule.Next(next) {
+				occurrence = next
+			}
+		}
+
+		if occurrence.After(today) {
+			continue
+		}
+
+		task := Task{
+			WritePending: true,
+			Status:       STATUS_PENDING,
+			Summary:      r.Summary,
+			Notes:        r.Notes,
+			Tags:         slices.Clone(r.Tags),
+			Project:      r.Project,
+			Priority:     r.Priority,
+			Due:          occurrence,
+			Parent:       r.UUID,
+		}
+
+		task, err = ts.LoadTask(task)
+		if err != nil {
+			return nil, err
+		}
+
+		r.LastRecurrence = occurrence
+		if err := ts.UpdateTask(r); err != nil {
+			return nil, err
+		}
+
+		created = append(created, task)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,150 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	type testCase struct {
+		input    string
+		expected string
+		valid    bool
+	}
+
+	testCases := []testCase{
+		{"daily", "freq=daily", true},
+		{"WEEKLY", "freq=weekly", true},
+		{"freq=weekly;byday=th,mo,th", "freq=weekly;byday=mo,th", true},
+		{"monthly;bymonthday=15", "freq=monthly;bymonthday=15", true},
+		{"freq=daily;interval=3;from=completion", "freq=daily;interval=3;from=completion", true},
+		{"", "", false},
+		{"hourly", "", false},
+		{"interval=2", "", false},
+		{"freq=daily;byday=mo", "", false},
+		{"freq=weekly;byday=xx", "", false},
+		{"freq=monthly;bymonthday=32", "", false},
+		{"freq=weekly;byday=mo;from=completion", "", false},
+	}
+
+	for _, tc := range testCases {
+		s, err := ParseSchedule(tc.input)
+		if !tc.valid {
+			assert.Error(t, err, tc.input)
+
+			continue
+		}
+
+		assert.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, s.String(), tc.input)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+
+	type testCase struct {
+		rule     string
+		after    time.Time
+		expected time.Time
+	}
+
+	// 2026-10-19 is a Monday
+	testCases := []testCase{
+		{"daily", date(2026, 10, 19), date(2026, 10, 20)},
+		{"daily;interval=3", date(2026, 10, 19), date(2026, 10, 22)},
+		{"weekly;byday=mo,th", date(2026, 10, 19), date(2026, 10, 22)},
+		{"weekly;byday=mo,th", date(2026, 10, 22), date(2026, 10, 26)},
+		{"weekly;byday=mo;interval=2", date(2026, 10, 19), date(2026, 11, 2)},
+		{"weekly;byday=su", date(2026, 10, 19), date(2026, 10, 25)},
+		{"monthly;bymonthday=15", date(2026, 10, 1), date(2026, 10, 15)},
+		{"monthly;bymonthday=15", date(2026, 10, 15), date(2026, 11, 15)},
+		{"monthly;bymonthday=31", date(2026, 10, 31), date(2026, 11, 30)},
+		{"monthly;bymonthday=31", date(2026, 11, 30), date(2026, 12, 31)},
+	}
+
+	for _, tc := range testCases {
+		s, err := ParseSchedule(tc.rule)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, s.Next(tc.after), tc.rule)
+	}
+}
+
+func TestGenerateRecurring(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	ts := newTestTaskSet()
+
+	weekly, err := ts.LoadTask(Task{
+		Status:  STATUS_RECURRING,
+		Summary: "rotate pager",
+		Recur:   "freq=weekly;byday=mo,th",
+		Created: monday.AddDate(0, 0, -14),
+	})
+	assert.NoError(t, err)
+
+	_, err = ts.LoadTask(Task{
+		Status:  STATUS_RECURRING,
+		Summary: "not yet",
+		Recur:   "freq=monthly;bymonthday=1",
+		Created: monday,
+	})
+	assert.NoError(t, err)
+
+	created, err := ts.GenerateRecurring(monday.Add(9 * time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, created, 1)
+	assert.Equal(t, weekly.UUID, created[0].Parent)
+	assert.Equal(t, monday, created[0].Due, "missed occurrences are skipped")
+
+	// an open instance exists, so nothing more is generated
+	created, err = ts.GenerateRecurring(monday.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	assert.Empty(t, created)
+}
+
+func TestGenerateRecurringFromCompletion(t *testing.T) {
+	// created on a Monday the 5th, completed on a Wednesday the 21st
+	created := time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2026, 10, 21, 17, 0, 0, 0, time.Local)
+	ts := newTestTaskSet()
+
+	weekly := mustLoadTask(t, ts, Task{
+		Status:  STATUS_RECURRING,
+		Summary: "water plants",
+		Recur:   "freq=weekly;from=completion",
+		Created: created,
+	})
+
+	monthly := mustLoadTask(t, ts, Task{
+		Status:  STATUS_RECURRING,
+		Summary: "pay rent",
+		Recur:   "freq=monthly;from=completion",
+		Created: created,
+	})
+
+	for _, r := range []Task{weekly, monthly} {
+		mustLoadTask(t, ts, Task{
+			Status:   STATUS_RESOLVED,
+			Summary:  r.Summary,
+			Parent:   r.UUID,
+			Created:  created,
+			Resolved: completed,
+		})
+	}
+
+	generated, err := ts.GenerateRecurring(time.Date(2026, 11, 21, 9, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Len(t, generated, 2)
+
+	due := make(map[string]time.Time)
+	for _, task := range generated {
+		due[task.Parent] = task.Due
+	}
+
+	assert.Equal(t, time.Date(2026, 10, 28, 0, 0, 0, 0, time.Local), due[weekly.UUID])
+	assert.Equal(t, time.Date(2026, 11, 21, 0, 0, 0, 0, time.Local), due[monthly.UUID])
+}
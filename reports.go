@@ -0,0 +1,59 @@
+package dstask
+
+// named reports are lists of tasks defined in the config file, eg.
+//
+//	[reports.stale]
+//	description = "Oldest work first"
+//	filter = "+work"
+//	sort = ["created"]
+//	columns = ["id", "age", "project", "summary"]
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Report is a named report, run with "dstask report <name>".
+type Report struct {
+	Description string `json:"description" toml:"description"`
+	// context string, see ParseContext
+	Filter string `json:"filter" toml:"filter"`
+	// sort keys, see ParseSortKeys. By default, tasks are ordered as by next.
+	Sort []string `json:"sort" toml:"sort"`
+	// By default, the columns of the task list.
+	Columns []string `json:"columns" toml:"columns"`
+	// statuses of tasks to include, including hidden statuses. By default,
+	// the tasks shown by next.
+	Statuses []string `json:"statuses" toml:"statuses"`
+}
+
+func (report Report) validate() error {
+	if report.Filter != "" {
+		filter, err := ParseContext(report.Filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+
+		if err := validateContext(filter); err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	if _, err := ParseSortKeys(report.Sort); err != nil {
+		return err
+	}
+
+	if report.Columns != nil {
+		if err := validateColumns(report.Columns); err != nil {
+			return err
+		}
+	}
+
+	for _, status := range report.Statuses {
+		if !slices.Contains(ALL_STATUSES, status) {
+			return fmt.Errorf("invalid status: %s", status)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package dstask
+
+// text search of task summaries and notes. Plain text matches from the start
+// of a word, case insensitively, so "serv" finds "server" but not "observe".
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	SEARCH_SUMMARY = "summary"
+	SEARCH_NOTES   = "notes"
+)
+
+// Search is a text search term, optionally restricted to the summary or notes
+// of a task.
+type Search struct {
+	// SEARCH_SUMMARY, SEARCH_NOTES or empty for both
+	Field   string
+	Pattern string
+	// Pattern is a regular expression, written as /regex/
+	Regexp bool
+}
+
+// compiled patterns, as a filter is matched against every task
+var searchRegexps sync.Map
+
+// ParseSearch parses a search term for the given field. The pattern may be a
+// /regex/.
+func ParseSearch(field, pattern string) (Search, error) {
+	s := Search{Field: field, Pattern: pattern}
+
+	if isRegexpPattern(pattern) {
+		s.Pattern = pattern[1 : len(pattern)-1]
+		s.Regexp = true
+	}
+
+	if s.Pattern == "" {
+		return Search{}, fmt.Errorf("empty search term: %s:", field)
+	}
+
+	if _, err := regexp.Compile(s.expr()); err != nil {
+		return Search{}, fmt.Errorf("invalid search regex: %s", err)
+	}
+
+	return s, nil
+}
+
+func isRegexpPattern(pattern string) bool {
+	return len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
+}
+
+// expr returns the regular expression for the pattern, without flags.
+func (s Search) expr() string {
+	if s.Regexp {
+		return s.Pattern
+	}
+
+	expr := regexp.QuoteMeta(s.Pattern)
+
+	// \b only makes sense before a word character
+	if r, _ := utf8.DecodeRuneInString(s.Pattern); unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+		expr = `\b` + expr
+	}
+
+	return expr
+}
+
+func (s Search) regexp() *regexp.Regexp {
+	expr := "(?i)" + s.expr()
+
+	if re, ok := searchRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(expr)
+	searchRegexps.Store(expr, re)
+
+	return re
+}
+
+// Matches returns true if the pattern is found in the summary or notes of
+// the task. Fields are searched separately, so a match cannot span both.
+func (s Search) Matches(t *Task) bool {
+	re := s.regexp()
+
+	return (s.Field != SEARCH_NOTES && re.MatchString(t.Summary)) ||
+		(s.Field != SEARCH_SUMMARY && re.MatchString(t.Notes))
+}
+
+func (s Search) String() string {
+	pattern := s.Pattern
+	if s.Regexp {
+		pattern = "/" + pattern + "/"
+	}
+
+	if s.Field != "" {
+		return s.Field + ":" + pattern
+	}
+
+	return pattern
+}
+
+// searches returns the search terms of the query, including its text and any
+// terms of the filter expression that are not negated.
+func (query Query) searches() []Search {
+	searches := slices.Clone(query.Searches)
+
+	if query.Text != "" {
+		searches = append(searches, Search{Pattern: query.Text})
+	}
+
+	if query.Expr != nil {
+		searches = append(searches, query.Expr.searches()...)
+	}
+
+	return searches
+}
+
+func (e *Expr) searches() []Search {
+	if e.Op == EXPR_NOT {
+		return nil
+	}
+
+	if e.Term != nil {
+		return e.Term.searches()
+	}
+
+	var searches []Search
+	for _, arg := range e.Args {
+		searches = append(searches, arg.searches()...)
+	}
+
+	return searches
+}
+
+// highlightRegexp returns a regular expression matching any of the search
+// terms, or nil if there are none.
+func highlightRegexp(searches []Search) *regexp.Regexp {
+	if len(searches) == 0 {
+		return nil
+	}
+
+	exprs := make([]string, 0, len(searches))
+	for _, s := range searches {
+		exprs = append(exprs, "(?:"+s.expr()+")")
+	}
+
+	return regexp.MustCompile("(?i)" + strings.Join(exprs, "|"))
+}
@@ -0,0 +1,64 @@
+package dstask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchMatches(t *testing.T) {
+	task := Task{Summary: "Restart the web server", Notes: "check the database logs"}
+
+	type testCase struct {
+		query    []string
+		expected bool
+	}
+
+	tests := []testCase{
+		{[]string{"serv"}, true},
+		{[]string{"SERVER"}, true},
+		{[]string{"erver"}, false},        // word boundary
+		{[]string{"server check"}, false}, // no match across fields
+		{[]string{"summary:web"}, true},
+		{[]string{"summary:database"}, false},
+		{[]string{"notes:database"}, true},
+		{[]string{"notes:web"}, false},
+		{[]string{"/web\\s+serv/"}, true},
+		{[]string{"/^check/"}, true},
+		{[]string{"summary:/^check/"}, false},
+		{[]string{"notes:/LOGS$/"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query[0], func(t *testing.T) {
+			assert.Equal(t, tc.expected, task.MatchesFilter(mustParseQuery(t, tc.query...)))
+		})
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	query := mustParseQuery(t, "next", "summary:/Web\\S+/", "notes:db", "/x|y/")
+
+	assert.Equal(t, []Search{
+		{Field: SEARCH_SUMMARY, Pattern: "Web\\S+", Regexp: true},
+		{Field: SEARCH_NOTES, Pattern: "db"},
+		{Pattern: "x|y", Regexp: true},
+	}, query.Searches)
+	assert.Equal(t, "summary:/Web\\S+/ notes:db /x|y/", query.String())
+
+	_, err := ParseSearch("", "/(/")
+	assert.Error(t, err)
+
+	// paths in new tasks are not regular expressions
+	query = mustParseQuery(t, "add", "clean", "/tmp/")
+	assert.Empty(t, query.Searches)
+	assert.Equal(t, "clean /tmp/", query.Text)
+}
+
+func TestHighlightRegexp(t *testing.T) {
+	query := mustParseQuery(t, "serv", "+x", "or", "notes:/d.t/", "not", "summary:web")
+	re := highlightRegexp(query.searches())
+
+	assert.Equal(t, "web [serv]er [dat]a", re.ReplaceAllString("web server data", "[${0}]"))
+	assert.Nil(t, highlightRegexp(nil))
+}
@@ -0,0 +1,122 @@
+package dstask
+
+// multi-key sorting of tasks, eg. due,-priority,project sorts by due date,
+// then by priority descending, then by project.
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortKey is a field to sort tasks by.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSortKeys parses sort keys, each a field optionally prefixed with - to
+// sort in descending order.
+func ParseSortKeys(keys []string) ([]SortKey, error) {
+	sortKeys := make([]SortKey, 0, len(keys))
+
+	for _, key := range keys {
+		field, descending := strings.CutPrefix(key, "-")
+
+		if _, ok := taskComparators[field]; !ok {
+			return nil, fmt.Errorf("invalid sort key: %s", field)
+		}
+
+		sortKeys = append(sortKeys, SortKey{Field: field, Descending: descending})
+	}
+
+	return sortKeys, nil
+}
+
+func (key SortKey) String() string {
+	if key.Descending {
+		return "-" + key.Field
+	}
+
+	return key.Field
+}
+
+// SORT_FIELDS are the fields tasks can be sorted by.
+var SORT_FIELDS = []string{
+	COLUMN_ID,
+	COLUMN_PRIORITY,
+	COLUMN_DUE,
+	COLUMN_PROJECT,
+	COLUMN_URGENCY,
+	COLUMN_SUMMARY,
+	COLUMN_CREATED,
+	COLUMN_RESOLVED,
+	COLUMN_AGE,
+	COLUMN_STATUS,
+}
+
+var taskComparators = map[string]func(a, b *Task) int{
+	COLUMN_ID:       func(a, b *Task) int { return cmp.Compare(a.ID, b.ID) },
+	COLUMN_PRIORITY: func(a, b *Task) int { return cmp.Compare(a.Priority, b.Priority) },
+	COLUMN_DUE:      func(a, b *Task) int { return compareTimes(a.Due, b.Due) },
+	COLUMN_PROJECT:  func(a, b *Task) int { return compareProjects(a.Project, b.Project) },
+	COLUMN_URGENCY:  func(a, b *Task) int { return cmp.Compare(a.Urgency, b.Urgency) },
+	COLUMN_SUMMARY:  func(a, b *Task) int { return cmp.Compare(strings.ToLower(a.Summary), strings.ToLower(b.Summary)) },
+	COLUMN_CREATED:  func(a, b *Task) int { return compareTimes(a.Created, b.Created) },
+	COLUMN_RESOLVED: func(a, b *Task) int { return compareTimes(a.Resolved, b.Resolved) },
+	// oldest first
+	COLUMN_AGE:    func(a, b *Task) int { return compareTimes(b.Created, a.Created) },
+	COLUMN_STATUS: func(a, b *Task) int { return cmp.Compare(a.Status, b.Status) },
+}
+
+// compareTimes orders zero times last, eg. tasks without a due date.
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+
+	return a.Compare(b)
+}
+
+// compareTasks compares tasks by each key in turn, until they differ.
+func compareTasks(keys []SortKey, a, b *Task) int {
+	for _, key := range keys {
+		c := taskComparators[key.Field](a, b)
+		if key.Descending {
+			c = -c
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// SortBy sorts the tasks by the given keys. Tasks that compare equal keep
+// their order.
+func (ts *TaskSet) SortBy(keys []SortKey) {
+	slices.SortStableFunc(ts.tasks, func(a, b *Task) int {
+		return compareTasks(keys, a, b)
+	})
+}
+
+// sortByQuery sorts the tasks by the sort order of the filters applied, if
+// any, see Query.Sort. Returns false if there was none.
+func (ts *TaskSet) sortByQuery() bool {
+	if len(ts.sortKeys) == 0 {
+		return false
+	}
+
+	ts.SortBy(ts.sortKeys)
+
+	return true
+}
@@ -0,0 +1,60 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSortKeys(t *testing.T) {
+	keys, err := ParseSortKeys([]string{"due", "-priority", "project"})
+	assert.NoError(t, err)
+	assert.Equal(t, []SortKey{
+		{Field: COLUMN_DUE},
+		{Field: COLUMN_PRIORITY, Descending: true},
+		{Field: COLUMN_PROJECT},
+	}, keys)
+	assert.Equal(t, "-priority", keys[1].String())
+
+	_, err = ParseSortKeys([]string{"-colour"})
+	assert.Error(t, err)
+
+	// not sortable
+	_, err = ParseSortKeys([]string{COLUMN_TAGS})
+	assert.Error(t, err)
+}
+
+func TestSortBy(t *testing.T) {
+	ts := newTestTaskSet()
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "a", Priority: PRIORITY_LOW, Project: "web"})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "b", Priority: PRIORITY_HIGH, Due: day.AddDate(0, 0, 1)})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "c", Priority: PRIORITY_LOW, Project: "infra"})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "d", Priority: PRIORITY_HIGH, Due: day})
+
+	keys, err := ParseSortKeys([]string{"due", "-priority", "project"})
+	assert.NoError(t, err)
+
+	ts.SortBy(keys)
+
+	var summaries []string
+	for _, task := range ts.Tasks() {
+		summaries = append(summaries, task.Summary)
+	}
+
+	// tasks without a due date last
+	assert.Equal(t, []string{"d", "b", "c", "a"}, summaries)
+}
+
+func TestFormatAge(t *testing.T) {
+	day := 24 * time.Hour
+
+	assert.Equal(t, "5m", formatAge(5*time.Minute))
+	assert.Equal(t, "3h", formatAge(3*time.Hour))
+	assert.Equal(t, "13d", formatAge(13*day))
+	assert.Equal(t, "3w", formatAge(21*day))
+	assert.Equal(t, "6mo", formatAge(180*day))
+	assert.Equal(t, "3y", formatAge(3*365*day))
+}
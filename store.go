@@ -0,0 +1,150 @@
+package dstask
+
+// storage of tasks. Tasks are stored by status and UUID; IDs are local state,
+// see IdsMap.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Store is where tasks are kept. A task is stored under exactly one status.
+type Store interface {
+	// List returns the UUIDs of the tasks stored with the status. Entries
+	// that may not be tasks are included, for Load to report.
+	List(status string) ([]string, error)
+	// Load returns the task stored with the status. The error wraps
+	// ErrTaskNotFound if there is none.
+	Load(status, uuid string) (Task, error)
+	// Save stores the task with its status, removing it from any other.
+	Save(task Task) error
+	// Delete removes the task, whatever its status. The error wraps
+	// ErrTaskNotFound if there is none.
+	Delete(uuid string) error
+}
+
+// ModTimeStore is implemented by stores that can tell when a task was last
+// saved. A crash while saving, or a merge, can leave copies of a task with
+// different statuses; LoadTaskSet keeps the newest, see isNewerCopy.
+type ModTimeStore interface {
+	ModTime(status, uuid string) (time.Time, error)
+}
+
+// the kinds of store of the store setting
+const (
+	// YAML files in the git repository, see GitStore
+	STORE_GIT = "git"
+	// a bolt database local to the machine, see BoltStore. It's not committed,
+	// so tasks are not synced or undone with git.
+	STORE_BOLT = "bolt"
+)
+
+var STORES = []string{STORE_GIT, STORE_BOLT}
+
+// bolt databases opened by OpenStore, by path. A database can only be open
+// once at a time. See CloseStores.
+var boltStores = map[string]*BoltStore{}
+
+// OpenStore opens the store of the store setting. The store stays open for
+// later calls, until CloseStores.
+func (conf Config) OpenStore() (Store, error) {
+	switch conf.Store {
+	case STORE_BOLT:
+		path := conf.BoltFile
+
+		if store, ok := boltStores[path]; ok {
+			return store, nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("failed to create directories for %s: %w", path, err)
+		}
+
+		// the database used to be in the repository, and committed
+		oldPath := filepath.Join(conf.Repo, BOLT_FILENAME)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.Rename(oldPath, path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to move %s to %s: %w", oldPath, path, err)
+			}
+		}
+
+		store, err := OpenBoltStore(path)
+		if err != nil {
+			return nil, err
+		}
+
+		boltStores[path] = store
+
+		return store, nil
+	default:
+		return NewGitStore(conf.Repo), nil
+	}
+}
+
+// CloseStores closes the stores opened by OpenStore, releasing the lock on
+// each database.
+func CloseStores() error {
+	var errs []error
+
+	for path, store := range boltStores {
+		errs = append(errs, store.Close())
+		delete(boltStores, path)
+	}
+
+	return errors.Join(errs...)
+}
+
+// LoadTaskSet loads a TaskSet from the store of the store setting.
+func (conf Config) LoadTaskSet(includeResolved bool) (*TaskSet, error) {
+	store, err := conf.OpenStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadTaskSet(store, conf.Repo, conf.IDsFile, includeResolved)
+}
+
+// marshalTask encodes a task to be stored. The status is left out, as it is
+// where the task is stored.
+func marshalTask(t Task) ([]byte, error) {
+	t.Status = ""
+
+	data, err := yaml.Marshal(&t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task %s: %w", t, err)
+	}
+
+	return data, nil
+}
+
+// unmarshalTask decodes a task stored under the UUID and status. name is the
+// name of the entry, for errors.
+func unmarshalTask(data []byte, uuid, status, name string) (Task, error) {
+	t := Task{
+		UUID:   uuid,
+		Status: status,
+	}
+
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return Task{}, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+
+	t.Status = status
+
+	return t, nil
+}
+
+// validateStatus returns an error if the task can't be stored with its status.
+func validateStatus(t Task) error {
+	if !slices.Contains(ALL_STATUSES, t.Status) {
+		return fmt.Errorf("invalid status of task %s: %q", t.UUID, t.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package dstask
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// the database of BoltStore, in the local state directory, see
+// Config.BoltFile
+const BOLT_FILENAME = "tasks.db"
+
+// BoltStore stores tasks in an embedded key/value database, with a bucket per
+// status of YAML encoded tasks by UUID. Git can't diff or merge a database,
+// so it's not committed: tasks stay on the machine, and are not synced or
+// undone with git, unlike those of GitStore.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the database at path, creating it if need be.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("database %s is in use by another dstask", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) List(status string) ([]string, error) {
+	var uuids []string
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(status))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, _ []byte) error {
+			uuids = append(uuids, string(k))
+
+			return nil
+		})
+	})
+
+	return uuids, err
+}
+
+func (s *BoltStore) Load(status, uuid string) (Task, error) {
+	if !IsValidUUID4String(uuid) {
+		return Task{}, fmt.Errorf("key does not encode UUID %s", uuid)
+	}
+
+	var t Task
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var data []byte
+
+		if bucket := tx.Bucket([]byte(status)); bucket != nil {
+			data = bucket.Get([]byte(uuid))
+		}
+
+		if data == nil {
+			return fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+		}
+
+		// data is only valid during the transaction
+		var err error
+		t, err = unmarshalTask(data, uuid, status, uuid)
+
+		return err
+	})
+
+	return t, err
+}
+
+func (s *BoltStore) Save(t Task) error {
+	if err := validateStatus(t); err != nil {
+		return err
+	}
+
+	data, err := marshalTask(t)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := boltRemove(tx, t.UUID, t.Status); err != nil {
+			return err
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists([]byte(t.Status))
+		if err != nil {
+			return err
+		}
+
+		if err := bucket.Put([]byte(t.UUID), data); err != nil {
+			return fmt.Errorf("failed to write task %s: %w", t, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *BoltStore) Delete(uuid string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		found, err := boltRemove(tx, uuid, "")
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+		}
+
+		return nil
+	})
+}
+
+// boltRemove removes the task from the bucket of every status except keep,
+// reporting whether there were any copies.
+func boltRemove(tx *bolt.Tx, uuid, keep string) (bool, error) {
+	var found bool
+
+	for _, status := range ALL_STATUSES {
+		bucket := tx.Bucket([]byte(status))
+		if status == keep || bucket == nil || bucket.Get([]byte(uuid)) == nil {
+			continue
+		}
+
+		if err := bucket.Delete([]byte(uuid)); err != nil {
+			return found, fmt.Errorf("could not remove task %s: %w", uuid, err)
+		}
+
+		found = true
+	}
+
+	return found, nil
+}
@@ -0,0 +1,149 @@
+package dstask
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GitStore stores tasks as YAML files in the git repository, in a directory
+// by status, eg. pending/<uuid>.yml. This is the default, so that tasks can
+// be merged by git when syncing. See etc/DATABASE_FORMAT.md.
+type GitStore struct {
+	repoPath string
+}
+
+func NewGitStore(repoPath string) *GitStore {
+	return &GitStore{repoPath: repoPath}
+}
+
+func (s *GitStore) path(status, uuid string) string {
+	return filepath.Join(s.repoPath, status, uuid+".yml")
+}
+
+func (s *GitStore) List(status string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(s.repoPath, status))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// we do not guarantee that all status directories exist
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	uuids := make([]string, 0, len(files))
+
+	for _, finfo := range files {
+		// Discard hidden files like .gitkeep
+		if strings.HasPrefix(finfo.Name(), ".") {
+			continue
+		}
+
+		// other files are listed whole, for Load to report
+		name := finfo.Name()
+		if uuid, ok := strings.CutSuffix(name, ".yml"); ok && IsValidUUID4String(uuid) {
+			name = uuid
+		}
+
+		uuids = append(uuids, name)
+	}
+
+	return uuids, nil
+}
+
+func (s *GitStore) Load(status, uuid string) (Task, error) {
+	if !IsValidUUID4String(uuid) {
+		return Task{}, fmt.Errorf("filename does not encode UUID %s", uuid)
+	}
+
+	name := uuid + ".yml"
+
+	data, err := os.ReadFile(s.path(status, uuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+	} else if err != nil {
+		return Task{}, fmt.Errorf("failed to read %s", name)
+	}
+
+	return unmarshalTask(data, uuid, status, name)
+}
+
+func (s *GitStore) ModTime(status, uuid string) (time.Time, error) {
+	finfo, err := os.Stat(s.path(status, uuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	return finfo.ModTime(), nil
+}
+
+func (s *GitStore) Save(t Task) error {
+	if err := validateStatus(t); err != nil {
+		return err
+	}
+
+	data, err := marshalTask(t)
+	if err != nil {
+		return err
+	}
+
+	filepath, err := GetRepoPath(s.repoPath, t.Status, t.UUID+".yml")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write task %s: %w", t, err)
+	}
+
+	// Only one copy should exist, at most. The task is written first, so that
+	// if interrupted there are two copies, rather than none; LoadTaskSet
+	// keeps the newest.
+	_, err = s.remove(t.UUID, t.Status)
+
+	return err
+}
+
+func (s *GitStore) Delete(uuid string) error {
+	found, err := s.remove(uuid, "")
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+	}
+
+	return nil
+}
+
+// remove removes the task from every status directory except keep, reporting
+// whether there were any copies.
+func (s *GitStore) remove(uuid, keep string) (bool, error) {
+	var found bool
+
+	for _, status := range ALL_STATUSES {
+		if status == keep {
+			continue
+		}
+
+		filepath := s.path(status, uuid)
+
+		err := os.Remove(filepath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return found, fmt.Errorf("could not remove task %s: %w", filepath, err)
+		}
+
+		found = true
+	}
+
+	return found, nil
+}
@@ -0,0 +1,76 @@
+package dstask
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// MemoryStore keeps tasks in memory, encoded as they would be stored, for
+// tests.
+type MemoryStore struct {
+	// encoded tasks by UUID, by status
+	tasks map[string]map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tasks: make(map[string]map[string][]byte)}
+}
+
+func (s *MemoryStore) List(status string) ([]string, error) {
+	return slices.Sorted(maps.Keys(s.tasks[status])), nil
+}
+
+func (s *MemoryStore) Load(status, uuid string) (Task, error) {
+	data, ok := s.tasks[status][uuid]
+	if !ok {
+		return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+	}
+
+	return unmarshalTask(data, uuid, status, uuid)
+}
+
+func (s *MemoryStore) Save(t Task) error {
+	if err := validateStatus(t); err != nil {
+		return err
+	}
+
+	data, err := marshalTask(t)
+	if err != nil {
+		return err
+	}
+
+	s.remove(t.UUID)
+
+	if s.tasks[t.Status] == nil {
+		s.tasks[t.Status] = make(map[string][]byte)
+	}
+
+	s.tasks[t.Status][t.UUID] = data
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(uuid string) error {
+	if !s.remove(uuid) {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, uuid)
+	}
+
+	return nil
+}
+
+// remove removes the task from every status, reporting whether there were
+// any copies.
+func (s *MemoryStore) remove(uuid string) bool {
+	var found bool
+
+	for _, tasks := range s.tasks {
+		if _, ok := tasks[uuid]; ok {
+			delete(tasks, uuid)
+
+			found = true
+		}
+	}
+
+	return found
+}
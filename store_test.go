@@ -0,0 +1,279 @@
+package dstask
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testStores returns a store of each kind, empty.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	bolt, err := OpenBoltStore(filepath.Join(t.TempDir(), BOLT_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { bolt.Close() })
+
+	return map[string]Store{
+		"git":    NewGitStore(t.TempDir()),
+		"memory": NewMemoryStore(),
+		"bolt":   bolt,
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			task := Task{
+				UUID:     "f9ce7a2e-4dba-4bc0-a4b5-8d4b0bfa1e3c",
+				Status:   STATUS_PENDING,
+				Summary:  "write tests",
+				Tags:     []string{"work"},
+				Priority: PRIORITY_HIGH,
+				// as decoded
+				Subtasks:     []SubTask{},
+				Dependencies: []string{},
+			}
+
+			uuids, err := store.List(STATUS_PENDING)
+			assert.NoError(t, err)
+			assert.Empty(t, uuids)
+
+			_, err = store.Load(STATUS_PENDING, task.UUID)
+			assert.ErrorIs(t, err, ErrTaskNotFound)
+
+			assert.NoError(t, store.Save(task))
+
+			uuids, err = store.List(STATUS_PENDING)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{task.UUID}, uuids)
+
+			loaded, err := store.Load(STATUS_PENDING, task.UUID)
+			assert.NoError(t, err)
+			assert.Equal(t, task, loaded)
+
+			// changing status moves the task
+			task.Status = STATUS_ACTIVE
+			assert.NoError(t, store.Save(task))
+
+			uuids, err = store.List(STATUS_PENDING)
+			assert.NoError(t, err)
+			assert.Empty(t, uuids)
+
+			loaded, err = store.Load(STATUS_ACTIVE, task.UUID)
+			assert.NoError(t, err)
+			assert.Equal(t, STATUS_ACTIVE, loaded.Status)
+
+			assert.NoError(t, store.Delete(task.UUID))
+			assert.ErrorIs(t, store.Delete(task.UUID), ErrTaskNotFound)
+
+			_, err = store.Load(STATUS_ACTIVE, task.UUID)
+			assert.ErrorIs(t, err, ErrTaskNotFound)
+
+			assert.Error(t, store.Save(Task{UUID: task.UUID, Status: "../pending"}))
+		})
+	}
+}
+
+func TestGitStoreLoadInvalid(t *testing.T) {
+	repoPath := t.TempDir()
+	store := NewGitStore(repoPath)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(repoPath, STATUS_PENDING), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, STATUS_PENDING, ".gitkeep"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, STATUS_PENDING, "notes.txt"), nil, 0o600))
+
+	uuids, err := store.List(STATUS_PENDING)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, uuids, "hidden files are skipped")
+
+	_, err = store.Load(STATUS_PENDING, "notes.txt")
+	assert.ErrorContains(t, err, "filename does not encode UUID")
+}
+
+func TestLoadTaskSetFromStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	for _, task := range []Task{
+		{UUID: "1c4dbb6b-4c7a-4b3e-9a3c-0b3c8b1f0e01", Status: STATUS_PENDING, Summary: "a"},
+		{UUID: "1c4dbb6b-4c7a-4b3e-9a3c-0b3c8b1f0e02", Status: STATUS_RESOLVED, Summary: "b", Resolved: time.Now()},
+	} {
+		assert.NoError(t, store.Save(task))
+	}
+
+	repoPath := t.TempDir()
+	idsFile := filepath.Join(repoPath, "ids.bin")
+
+	ts, err := LoadTaskSet(store, repoPath, idsFile, false)
+	assert.NoError(t, err)
+	assert.Len(t, ts.AllTasks(), 1, "resolved tasks are not loaded")
+
+	task := mustGetByID(t, ts, 1)
+	task.Summary = "c"
+	assert.NoError(t, ts.UpdateTask(task))
+	assert.NoError(t, ts.SavePendingChanges())
+
+	loaded, err := store.Load(STATUS_PENDING, task.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", loaded.Summary)
+
+	ts, err = LoadTaskSet(store, repoPath, idsFile, true)
+	assert.NoError(t, err)
+	assert.Len(t, ts.AllTasks(), 2)
+}
+
+func TestReconcile(t *testing.T) {
+	repoPath := t.TempDir()
+	store := NewGitStore(repoPath)
+
+	hourAgo := time.Now().Add(-time.Hour)
+
+	task := Task{
+		UUID:     "5b0c8e3e-9d1f-4a7c-8e2b-6f1a2b3c4d01",
+		Status:   STATUS_PENDING,
+		Summary:  "a",
+		Created:  hourAgo,
+		Modified: hourAgo,
+	}
+
+	resolved := task
+	resolved.Status = STATUS_RESOLVED
+	resolved.Resolved = time.Now()
+	resolved.Modified = time.Now()
+
+	// as if interrupted after writing the resolved copy. The file of the
+	// pending copy is newer, as after a git checkout, but that's not used.
+	assert.NoError(t, store.Save(resolved))
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoPath, STATUS_PENDING), 0o700))
+	assert.NoError(t, writeFileAtomic(store.path(STATUS_PENDING, task.UUID), mustMarshalTask(t, task), 0o600))
+
+	hourLater := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(store.path(STATUS_PENDING, task.UUID), hourLater, hourLater))
+
+	ts := newTestTaskSet()
+	ts.store = store
+	ts.idsFilePath = filepath.Join(repoPath, "ids.bin")
+	mustLoadTask(t, ts, task)
+
+	assert.NoError(t, ts.reconcile(resolved))
+
+	reconciled := ts.AllTasks()
+	assert.Len(t, reconciled, 1)
+	assert.Equal(t, STATUS_RESOLVED, reconciled[0].Status, "newest copy kept")
+	assert.Equal(t, 0, reconciled[0].ID)
+	assert.True(t, reconciled[0].WritePending)
+
+	_, err := ts.GetByID(1)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	assert.NoError(t, ts.SavePendingChanges())
+
+	_, err = store.Load(STATUS_PENDING, task.UUID)
+	assert.ErrorIs(t, err, ErrTaskNotFound, "other copy removed")
+
+	stored, err := store.Load(STATUS_RESOLVED, task.UUID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Modified.After(resolved.Modified), "saving records the time")
+
+	// as if interrupted while reopening it, a newer pending copy is kept
+	ts = newTestTaskSet()
+	ts.store = store
+	mustLoadTask(t, ts, stored)
+
+	reopened := task
+	reopened.Modified = stored.Modified.Add(time.Minute)
+
+	assert.NoError(t, ts.reconcile(reopened))
+	assert.Equal(t, STATUS_PENDING, mustGetByID(t, ts, 1).Status)
+}
+
+func TestIsNewerCopy(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	pending := Task{UUID: "5b0c8e3e-9d1f-4a7c-8e2b-6f1a2b3c4d02", Status: STATUS_PENDING}
+	active := pending
+	active.Status = STATUS_ACTIVE
+
+	resolved := pending
+	resolved.Status = STATUS_RESOLVED
+	resolved.Resolved = now
+
+	// saved before the time was recorded, the resolved copy is newer
+	newer, err := isNewerCopy(store, resolved, pending)
+	assert.NoError(t, err)
+	assert.True(t, newer)
+
+	newer, err = isNewerCopy(store, pending, resolved)
+	assert.NoError(t, err)
+	assert.False(t, newer)
+
+	// nothing to tell them apart
+	newer, err = isNewerCopy(store, active, pending)
+	assert.NoError(t, err)
+	assert.False(t, newer)
+
+	// the recorded time wins
+	pending.Modified = now
+	newer, err = isNewerCopy(store, pending, resolved)
+	assert.NoError(t, err)
+	assert.True(t, newer)
+
+	resolved.Modified = now.Add(time.Second)
+	newer, err = isNewerCopy(store, pending, resolved)
+	assert.NoError(t, err)
+	assert.False(t, newer)
+}
+
+func mustMarshalTask(t *testing.T, task Task) []byte {
+	t.Helper()
+
+	data, err := marshalTask(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestOpenStoreBolt(t *testing.T) {
+	repo := t.TempDir()
+	conf := Config{
+		Repo:     repo,
+		Store:    STORE_BOLT,
+		BoltFile: filepath.Join(repo, ".git", "dstask", BOLT_FILENAME),
+	}
+
+	// a database from before it moved out of the repository
+	old, err := OpenBoltStore(filepath.Join(repo, BOLT_FILENAME))
+	assert.NoError(t, err)
+	assert.NoError(t, old.Save(Task{UUID: "f9ce7a2e-4dba-4bc0-a4b5-8d4b0bfa1e3c", Status: STATUS_PENDING}))
+	assert.NoError(t, old.Close())
+
+	store, err := conf.OpenStore()
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(repo, BOLT_FILENAME))
+	assert.FileExists(t, conf.BoltFile)
+
+	uuids, err := store.List(STATUS_PENDING)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f9ce7a2e-4dba-4bc0-a4b5-8d4b0bfa1e3c"}, uuids)
+
+	again, err := conf.OpenStore()
+	assert.NoError(t, err)
+	assert.Same(t, store, again)
+
+	assert.NoError(t, CloseStores())
+	assert.Empty(t, boltStores)
+
+	// the lock is released
+	reopened, err := OpenBoltStore(conf.BoltFile)
+	assert.NoError(t, err)
+	assert.NoError(t, reopened.Close())
+}
@@ -2,6 +2,8 @@ package dstask
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/mattn/go-runewidth"
@@ -12,6 +14,10 @@ type Table struct {
 	Rows      [][]string
 	RowStyles []RowStyle
 	Width     int
+	// matches in the given column are shown in reverse video, eg. search
+	// terms
+	Highlight       *regexp.Regexp
+	HighlightColumn int
 }
 
 type RowStyle struct {
@@ -101,6 +107,7 @@ func (t *Table) Render() {
 	}
 
 	rows := append([][]string{t.Header}, t.Rows...)
+	colour := colourEnabled(os.Stdout)
 
 	for i, row := range rows {
 		mode := t.RowStyles[i].Mode
@@ -126,26 +133,42 @@ func (t *Table) Render() {
 		}
 
 		cells := row
-		for i, w := range widths {
-			trimmed := FixStr(cells[i], w)
+		for j, w := range widths {
+			trimmed := FixStr(cells[j], w)
+			hasNotes := strings.Contains(trimmed, " "+NOTE_MODE_KEYWORD+" ")
+
+			if hasNotes {
+				// the markup is replaced by a single space below
+				trimmed = FixStr(cells[j], w+2)
+			}
+
+			if colour && t.Highlight != nil && i > 0 && j == t.HighlightColumn {
+				trimmed = t.Highlight.ReplaceAllString(trimmed, SGR_REVERSE+"${0}"+SGR_REVERSE_OFF)
+			}
 
 			// support ' / ' markup -- show notes faded. Insert ANSI escape
-			// formatting, ensuring reset to original colour for given row.
-			if strings.Contains(trimmed, " "+NOTE_MODE_KEYWORD+" ") {
+			// formatting, ensuring reset to original style for given row.
+			if hasNotes && colour {
 				trimmed = strings.Replace(
-					FixStr(cells[i], w+2),
+					trimmed,
 					" "+NOTE_MODE_KEYWORD+" ",
-					fmt.Sprintf("\033[38;5;%dm ", FG_NOTE),
+					sgr(mode, FG_NOTE, bg)+" ",
 					1,
-				) + fmt.Sprintf("\033[38;5;%dm", fg)
+				) + sgr(mode, fg, bg)
+			} else if hasNotes {
+				trimmed = strings.Replace(trimmed, " "+NOTE_MODE_KEYWORD+" ", " ", 1)
 			}
 
-			cells[i] = trimmed
+			cells[j] = trimmed
 		}
 
 		line := strings.Join(cells, strings.Repeat(" ", TABLE_COL_GAP))
 
 		// print style, line then reset
-		fmt.Printf("\033[%d;38;5;%d;48;5;%dm%s\033[0m\n", mode, fg, bg, line)
+		if colour {
+			fmt.Println(sgr(mode, fg, bg) + line + SGR_RESET)
+		} else {
+			fmt.Println(line)
+		}
 	}
 }
@@ -5,19 +5,16 @@ package dstask
 import (
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
 	"slices"
 	"sort"
 	"strings"
 	"time"
-
-	yaml "gopkg.in/yaml.v2"
 )
 
 type SubTask struct {
-	Summary  string
-	Resolved bool
+	Summary  string `json:"summary"`
+	Resolved bool   `json:"resolved"`
 }
 
 // Task is our representation of tasks added at the command line and serialized
@@ -44,17 +41,31 @@ type Task struct {
 	Tags    []string `json:"tags"`
 	Project string   `json:"project"`
 	// see const.go for PRIORITY_ strings
-	Priority    string    `json:"priority"`
-	DelegatedTo string    `json:"-"`
-	Subtasks    []SubTask `json:"-"`
+	Priority string `json:"priority"`
+	// person a delegated task is waiting on
+	DelegatedTo string    `json:"delegatedTo"`
+	Subtasks    []SubTask `json:"subtasks"`
 	// uuids of tasks that this task depends on
-	// blocked status can be derived.
-	// TODO possible filter: :blocked. Also, :overdue
-	Dependencies []string `json:"-"`
+	Dependencies []string `json:"dependencies"`
+	// derived by the TaskSet: a dependency is not resolved yet
+	Blocked bool `json:"blocked" yaml:"-"`
+	// derived by the TaskSet, see Urgency
+	Urgency float64 `json:"urgency" yaml:"-"`
+	// schedule of a recurring task, see ParseSchedule
+	Recur string `json:"recur,omitempty" yaml:",omitempty"`
+	// day of the last instance materialised from a recurring task
+	LastRecurrence time.Time `json:"-" yaml:",omitempty"`
+	// uuid of the recurring task this task is an instance of
+	Parent string `json:"parent,omitempty" yaml:",omitempty"`
 
 	Created  time.Time `json:"created"`
 	Resolved time.Time `json:"resolved"`
 	Due      time.Time `json:"due"`
+	// deferred tasks become pending again on this day
+	Wait time.Time `json:"wait" yaml:",omitempty"`
+	// when the task was last saved, to tell which of several copies of it is
+	// the newest, see isNewerCopy
+	Modified time.Time `json:"-" yaml:",omitempty"`
 
 	// TaskSet uses this to indicate if a given task is excluded by a filter
 	// (context etc)
@@ -104,44 +115,16 @@ func (t Task) Equals(t2 Task) bool {
 		return false
 	}
 
-	if !t2.Created.Equal(t.Created) || !t2.Resolved.Equal(t.Resolved) || !t2.Due.Equal(t.Due) {
+	if t2.Recur != t.Recur || t2.Parent != t.Parent || !t2.LastRecurrence.Equal(t.LastRecurrence) {
 		return false
 	}
 
-	return true
-}
-
-// Unmarshal a Task from disk. We explicitly pass status, because the caller
-// already knows the status, and can override the status declared in yaml.
-func unmarshalTask(path string, finfo os.DirEntry, ids IdsMap, status string) (Task, error) {
-	if len(finfo.Name()) != TASK_FILENAME_LEN {
-		return Task{}, fmt.Errorf("filename does not encode UUID %s (wrong length)", finfo.Name())
-	}
-
-	uuid := finfo.Name()[0:36]
-	if !IsValidUUID4String(uuid) {
-		return Task{}, fmt.Errorf("filename does not encode UUID %s", finfo.Name())
-	}
-
-	t := Task{
-		UUID:   uuid,
-		Status: status,
-		ID:     ids[uuid],
-	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return Task{}, fmt.Errorf("failed to read %s", finfo.Name())
-	}
-
-	err = yaml.Unmarshal(data, &t)
-	if err != nil {
-		return Task{}, fmt.Errorf("failed to unmarshal %s", finfo.Name())
+	if !t2.Created.Equal(t.Created) || !t2.Resolved.Equal(t.Resolved) || !t2.Due.Equal(t.Due) ||
+		!t2.Wait.Equal(t.Wait) {
+		return false
 	}
 
-	t.Status = status
-
-	return t, nil
+	return true
 }
 
 func (t Task) String() string {
@@ -170,42 +153,102 @@ func (t *Task) MatchesFilter(query Query) bool {
 		}
 	}
 
-	if StrSliceContains(query.AntiProjects, t.Project) {
+	// projects include their descendants
+	for _, project := range query.AntiProjects {
+		if ProjectContains(project, t.Project) {
+			return false
+		}
+	}
+
+	if query.Project != "" && !ProjectContains(query.Project, t.Project) {
 		return false
 	}
 
-	if query.Project != "" && t.Project != query.Project {
+	if (!query.Due.IsZero() || query.DateFilter == "overdue") && !t.matchesDue(query) {
 		return false
 	}
 
-	if !query.Due.IsZero() {
-		if t.Due.IsZero() {
-			return false
-		}
-		if query.DateFilter == "after" && t.Due.Before(query.Due) {
-			return false
-		}
-		if query.DateFilter == "before" && t.Due.After(query.Due) {
-			return false
-		}
-		if (query.DateFilter == "on" || query.DateFilter == "in") && !t.Due.Equal(query.Due) {
-			return false
-		}
-		if query.DateFilter == "" && !t.Due.Equal(query.Due) {
+	if query.Priority != "" && !t.matchesPriority(query) {
+		return false
+	}
+
+	if query.Blocked && !t.Blocked {
+		return false
+	}
+
+	if query.Unblocked && t.Blocked {
+		return false
+	}
+
+	if query.DelegatedTo != "" && !strings.EqualFold(t.DelegatedTo, query.DelegatedTo) {
+		return false
+	}
+
+	if query.Text != "" && !(Search{Pattern: query.Text}).Matches(t) {
+		return false
+	}
+
+	for _, search := range query.Searches {
+		if !search.Matches(t) {
 			return false
 		}
 	}
 
-	if query.Priority != "" && t.Priority != query.Priority {
+	if query.Expr != nil && !query.Expr.Matches(t) {
 		return false
 	}
 
-	if query.Text != "" &&
-		!strings.Contains(strings.ToLower(t.Summary+t.Notes), strings.ToLower(query.Text)) {
+	return true
+}
+
+// matchesPriority compares priorities by number, so P0 < P1.
+func (t *Task) matchesPriority(query Query) bool {
+	if t.Priority == "" {
 		return false
 	}
 
-	return true
+	switch query.PriorityFilter {
+	case "<":
+		return t.Priority < query.Priority
+	case "<=":
+		return t.Priority <= query.Priority
+	case ">":
+		return t.Priority > query.Priority
+	case ">=":
+		return t.Priority >= query.Priority
+	default:
+		return t.Priority == query.Priority
+	}
+}
+
+// matchesDue returns true if the due date of the task satisfies the date
+// filter of the query.
+func (t *Task) matchesDue(query Query) bool {
+	if t.Due.IsZero() {
+		return false
+	}
+
+	switch query.DateFilter {
+	case "overdue":
+		return isPastDue(t.Due, time.Now())
+	case "after":
+		return !t.Due.Before(query.Due) &&
+			(query.DueBefore.IsZero() || !startOfDay(t.Due).After(startOfDay(query.DueBefore)))
+	case "before":
+		// by the day, like on
+		return !startOfDay(t.Due).After(startOfDay(query.Due))
+	case "within":
+		// not yet overdue, but due soon, by the day
+		return !isPastDue(t.Due, time.Now()) && !startOfDay(t.Due).After(startOfDay(query.Due))
+	default:
+		// due dates may have a time of day, but matching a date is by day
+		return isSameDay(t.Due, query.Due)
+	}
+}
+
+// IsOverdue returns true if the task is unresolved and past its due date.
+func (t *Task) IsOverdue(now time.Time) bool {
+	return !t.Due.IsZero() && isPastDue(t.Due, now) && t.Status != STATUS_RESOLVED
 }
 
 // Normalise mutates and sorts some of a task object's fields into a consistent
@@ -224,6 +267,9 @@ func (t *Task) Normalise() {
 	// tags must be unique
 	t.Tags = DeduplicateStrings(t.Tags)
 
+	sort.Strings(t.Dependencies)
+	t.Dependencies = DeduplicateStrings(t.Dependencies)
+
 	if t.Status == STATUS_RESOLVED {
 		// resolved task should not have ID as it's meaningless
 		t.ID = 0
@@ -248,15 +294,75 @@ func (t *Task) Validate() error {
 		return errors.New("invalid priority specified")
 	}
 
+	if err := validateProject(t.Project); err != nil {
+		return err
+	}
+
 	for _, uuid := range t.Dependencies {
 		if !IsValidUUID4String(uuid) {
 			return errors.New("invalid dependency UUID4")
 		}
+
+		if uuid == t.UUID {
+			return errors.New("task cannot depend on itself")
+		}
+	}
+
+	if t.Status == STATUS_RECURRING && t.Recur == "" {
+		return errors.New("recurring task has no schedule")
+	}
+
+	if t.Recur != "" {
+		if _, err := ParseSchedule(t.Recur); err != nil {
+			return err
+		}
+	}
+
+	if t.Status == STATUS_DELEGATED && t.DelegatedTo == "" {
+		return errors.New("delegated task has no delegate")
+	}
+
+	if t.Status == STATUS_DEFERRED && t.Wait.IsZero() {
+		return errors.New("deferred task has no wait date")
+	}
+
+	if t.Parent != "" && !IsValidUUID4String(t.Parent) {
+		return errors.New("invalid parent UUID4")
 	}
 
 	return nil
 }
 
+// SubtaskProgress returns resolved/total subtasks, eg. 3/5, or an empty
+// string if the task has no subtasks.
+func (t *Task) SubtaskProgress() string {
+	if len(t.Subtasks) == 0 {
+		return ""
+	}
+
+	var resolved int
+
+	for _, st := range t.Subtasks {
+		if st.Resolved {
+			resolved++
+		}
+	}
+
+	return fmt.Sprintf("%d/%d", resolved, len(t.Subtasks))
+}
+
+// HasIncompleteSubtasks returns true if any subtask is unresolved, including
+// GitHub-style checklist items in the notes.
+func (t *Task) HasIncompleteSubtasks() bool {
+	for _, st := range t.Subtasks {
+		if !st.Resolved {
+			return true
+		}
+	}
+
+	return strings.Contains(t.Notes, "- [ ] ")
+}
+
 // provides Summary + Last note if available.
 func (t *Task) LongSummary() string {
 	notes := strings.TrimSpace(t.Notes)
@@ -300,58 +406,16 @@ func (t *Task) Modify(query Query) {
 		t.Due = query.Due
 	}
 
-	if t.Notes != "" {
-		t.Notes += "\n"
+	if query.Recur != "" {
+		t.Recur = query.Recur
 	}
 
-	t.Notes += query.Note
-}
-
-func (t *Task) SaveToDisk(repoPath string) {
-	// save should be idempotent
-	t.WritePending = false
-
-	filepath := MustGetRepoPath(repoPath, t.Status, t.UUID+".yml")
-
-	if t.Deleted {
-		// Task is marked deleted. Delete from its current status directory.
-		if err := os.Remove(filepath); err != nil {
-			ExitFail("Could not remove task %s: %v", filepath, err)
-		}
-	} else {
-		// Task is not deleted, and will be written to disk to a directory
-		// that indicates its current status. We make a shallow copy first,
-		// and we set Status to empty string. This shallow copy is serialised
-		// to disk, with the Status field omitted. This avoids redundant data.
-		taskCp := *t
-		taskCp.Status = ""
-
-		d, err := yaml.Marshal(&taskCp)
-		if err != nil {
-			// TODO present error to user, specific error message is important
-			ExitFail("Failed to marshal task %s", t)
+	if query.Note != "" {
+		if t.Notes != "" {
+			t.Notes += "\n"
 		}
 
-		err = os.WriteFile(filepath, d, 0o600)
-		if err != nil {
-			ExitFail("Failed to write task %s", t)
-		}
-	}
-
-	// Delete task from other status directories. Only one copy should exist, at most.
-	for _, st := range ALL_STATUSES {
-		if st == t.Status {
-			continue
-		}
-
-		filepath := MustGetRepoPath(repoPath, st, t.UUID+".yml")
-
-		if _, err := os.Stat(filepath); !os.IsNotExist(err) {
-			err := os.Remove(filepath)
-			if err != nil {
-				ExitFail("Could not remove task %s: %v", filepath, err)
-			}
-		}
+		t.Notes += query.Note
 	}
 }
 
@@ -361,16 +425,25 @@ func (t *Task) ParseDueDateToStr() string {
 	if due.IsZero() {
 		return ""
 	}
-	if due.YearDay() == now.YearDay() && t.Due.Year() == now.Year() {
-		return "today"
-	}
-	if due.YearDay() == now.AddDate(0, 0, 1).YearDay() && t.Due.Year() == now.AddDate(0, 0, 1).Year() {
-		return "tomorrow"
+
+	var day string
+
+	switch {
+	case isSameDay(due, now):
+		day = "today"
+	case isSameDay(due, now.AddDate(0, 0, 1)):
+		day = "tomorrow"
+	case isSameDay(due, now.AddDate(0, 0, -1)):
+		day = "yesterday"
+	default:
+		day = formatDueDate(due)
 	}
-	if due.YearDay() == now.AddDate(0, 0, -1).YearDay() && t.Due.Year() == now.AddDate(0, 0, -1).Year() {
-		return "yesterday"
+
+	if hasTimeOfDay(due) {
+		return day + " " + due.Format("15:04")
 	}
-	return formatDueDate(due)
+
+	return day
 }
 
 func formatDueDate(due time.Time) string {
@@ -35,6 +35,25 @@ func TestModifyChangesProperties(t *testing.T) {
 				Notes: "Start Note\nQuery Note",
 			},
 		},
+		{ // No note, no change to notes
+			Task{
+				Notes: "Start Note",
+			},
+			Query{
+				Priority: "P1",
+			},
+			Task{
+				Notes:    "Start Note",
+				Priority: "P1",
+			},
+		},
+		{ // Delegate is only a filter
+			Task{},
+			Query{
+				DelegatedTo: "alice",
+			},
+			Task{},
+		},
 		{ // Priority when not set
 			Task{},
 			Query{
@@ -71,3 +90,23 @@ func TestModifyChangesProperties(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.task)
 	}
 }
+
+func TestSubtaskProgress(t *testing.T) {
+	task := Task{}
+	assert.Equal(t, "", task.SubtaskProgress())
+	assert.False(t, task.HasIncompleteSubtasks())
+
+	task.Subtasks = []SubTask{
+		{Summary: "one", Resolved: true},
+		{Summary: "two"},
+	}
+	assert.Equal(t, "1/2", task.SubtaskProgress())
+	assert.True(t, task.HasIncompleteSubtasks())
+
+	task.Subtasks[1].Resolved = true
+	assert.Equal(t, "2/2", task.SubtaskProgress())
+	assert.False(t, task.HasIncompleteSubtasks())
+
+	task.Notes = "- [ ] markdown checklist"
+	assert.True(t, task.HasIncompleteSubtasks())
+}
@@ -6,10 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
+	"slices"
 	"sort"
-	"strings"
 	"time"
 )
 
@@ -20,13 +18,29 @@ type TaskSet struct {
 	tasksByID   map[int]*Task
 	tasksByUUID map[string]*Task
 
+	// search terms of filters applied, to highlight
+	searches []Search
+
+	// sort order of the last filter applied with one, see Query.Sort
+	sortKeys []SortKey
+
+	// by project name, see LoadProjectRecords
+	projectRecords map[string]ProjectRecord
+
+	// where tasks are loaded from and saved to
+	store Store
+
 	// program metadata
 	idsFilePath string
 	repoPath    string
 }
 
 type Project struct {
-	Name          string `json:"name"`
+	ProjectRecord
+
+	Name string `json:"name"`
+	// closest ancestor, see ProjectAncestors
+	Parent        string `json:"parent,omitempty"`
 	Tasks         int    `json:"taskCount"`
 	TasksResolved int    `json:"resolvedCount"`
 	// if any task is in the active state
@@ -40,18 +54,22 @@ type Project struct {
 	Priority string `json:"priority"`
 }
 
-// LoadTaskSet constructs a TaskSet from a repo path..
-func LoadTaskSet(repoPath, idsFilePath string, includeResolved bool) (*TaskSet, error) {
+// LoadTaskSet constructs a TaskSet from the tasks of the store. repoPath is
+// the git repository, which has the project records and is committed to.
+func LoadTaskSet(store Store, repoPath, idsFilePath string, includeResolved bool) (*TaskSet, error) {
 	// Initialise an empty TaskSet
 	var ts TaskSet
 	ts.tasksByUUID = make(map[string]*Task)
 	ts.tasksByID = make(map[int]*Task)
 
+	ts.store = store
 	ts.idsFilePath = idsFilePath
 	ts.repoPath = repoPath
 
-	// Construct our options struct by calling our passed-in TaskSetOpt functions.
-	ids := LoadIds(idsFilePath)
+	ids, err := LoadIds(idsFilePath)
+	if err != nil {
+		return nil, err
+	}
 
 	var statuses []string
 
@@ -66,31 +84,31 @@ func LoadTaskSet(repoPath, idsFilePath string, includeResolved bool) (*TaskSet,
 		statuses = NON_RESOLVED_STATUSES
 	}
 
-	for _, status := range statuses {
-		dir := filepath.Join(repoPath, status)
+	// tasks with several copies, see reconcile
+	var reconciled int
 
-		files, err := os.ReadDir(dir)
+	for _, status := range statuses {
+		uuids, err := store.List(status)
 		if err != nil {
-			if os.IsNotExist(err) {
-				// Continuing here is necessary, because we do not guarantee
-				// that all status directories exist on program startup.
-				continue
-			}
-
 			return nil, err
 		}
 
-		for _, finfo := range files {
-			// Discard hidden files like .gitkeep
-			if strings.HasPrefix(finfo.Name(), ".") {
+		for _, uuid := range uuids {
+			t, err := store.Load(status, uuid)
+			if err != nil {
+				log.Printf("error loading task: %v\n", err)
+
 				continue
 			}
 
-			path := filepath.Join(dir, finfo.Name())
+			t.ID = ids[t.UUID]
 
-			t, err := unmarshalTask(path, finfo, ids, status)
-			if err != nil {
-				log.Printf("error loading task: %v\n", err)
+			if ts.tasksByUUID[t.UUID] != nil {
+				if err := ts.reconcile(t); err != nil {
+					log.Printf("error loading task: %v\n", err)
+				} else {
+					reconciled++
+				}
 
 				continue
 			}
@@ -103,6 +121,50 @@ func LoadTaskSet(repoPath, idsFilePath string, includeResolved bool) (*TaskSet,
 		}
 	}
 
+	if !includeResolved {
+		// resolved copies of tasks aren't listed, so look for them
+		for _, task := range slices.Clone(ts.tasks) {
+			t, err := store.Load(STATUS_RESOLVED, task.UUID)
+			if errors.Is(err, ErrTaskNotFound) {
+				continue
+			} else if err != nil {
+				log.Printf("error loading task: %v\n", err)
+
+				continue
+			}
+
+			if err := ts.reconcile(t); err != nil {
+				log.Printf("error loading task: %v\n", err)
+			} else {
+				reconciled++
+			}
+		}
+	}
+
+	records, err := LoadProjectRecords(repoPath)
+	if err != nil {
+		log.Printf("error loading projects: %v\n", err)
+	}
+
+	ts.projectRecords = records
+
+	// the copies not kept by reconcile are removed by saving the task
+	if reconciled > 0 {
+		if err := ts.SavePendingChanges(); err != nil {
+			return nil, err
+		}
+
+		if err := GitCommitQuiet(repoPath, "Reconciled %d task(s) with several copies", reconciled); err != nil {
+			return nil, err
+		}
+	}
+
+	// wake up deferred tasks. They stay deferred on disk until next saved, so
+	// that read-only commands don't commit.
+	ts.PromoteDeferred(time.Now())
+
+	ts.updateBlocked()
+
 	// hide some tasks by default. This is useful for things like templates and
 	// recurring tasks which are shown either directly or with show- commands
 	for _, task := range ts.tasks {
@@ -114,6 +176,25 @@ func LoadTaskSet(repoPath, idsFilePath string, includeResolved bool) (*TaskSet,
 	return &ts, nil
 }
 
+// PromoteDeferred makes deferred tasks with a wait date on or before the given
+// time pending again. Returns the number of tasks promoted. The tasks are not
+// marked to be written, so are saved as pending with their next change.
+func (ts *TaskSet) PromoteDeferred(now time.Time) int {
+	var n int
+
+	for _, task := range ts.tasks {
+		if task.Status != STATUS_DEFERRED || task.Wait.After(now) {
+			continue
+		}
+
+		task.Status = STATUS_PENDING
+		task.Wait = time.Time{}
+		n++
+	}
+
+	return n
+}
+
 func (ts *TaskSet) UnHide() {
 	for _, task := range ts.tasks {
 		if StrSliceContains(HIDDEN_STATUSES, task.Status) {
@@ -173,23 +254,117 @@ func (ts *TaskSet) SortByResolved(dir SortByDirection) {
 	}
 }
 
-// MustLoadTask is the same as LoadTask, except it exits on error.
-func (ts *TaskSet) MustLoadTask(task Task) Task {
-	newTask, err := ts.LoadTask(task)
+// SortByNext orders tasks as shown by next. Urgency must be up to date, see
+// UpdateUrgency.
+func (ts *TaskSet) SortByNext() {
+	ts.SortByCreated(Ascending)  // older tasks first (from top) like a FIFO queue
+	ts.SortByUrgency(Descending) // most urgent tasks first, of course
+	ts.SortByBlocked()           // nothing can be done about blocked tasks yet
+}
+
+// SortByBlocked moves blocked tasks after unblocked tasks, preserving order
+// otherwise.
+func (ts *TaskSet) SortByBlocked() {
+	sort.SliceStable(
+		ts.tasks,
+		func(i, j int) bool { return !ts.tasks[i].Blocked && ts.tasks[j].Blocked },
+	)
+}
+
+// reconcile chooses between the loaded task and another copy of it with a
+// different status, left by a crash while saving or by a merge. The newest
+// copy is kept, see isNewerCopy. The task is marked to be saved, which removes
+// the copy not kept.
+func (ts *TaskSet) reconcile(other Task) error {
+	loaded := ts.tasksByUUID[other.UUID]
+
+	keepOther, err := isNewerCopy(ts.store, other, *loaded)
 	if err != nil {
-		ExitFail("%s, task %s", err, task.UUID)
+		return err
+	}
+
+	if keepOther {
+		other.Normalise()
+
+		if err := other.Validate(); err != nil {
+			return fmt.Errorf("%w, task %s", err, other.UUID)
+		}
+
+		ts.unloadTask(other.UUID)
+
+		if _, err := ts.LoadTask(other); err != nil {
+			return err
+		}
+	}
+
+	ts.tasksByUUID[other.UUID].WritePending = true
+
+	return nil
+}
+
+// isNewerCopy reports whether a copy of a task is newer than another copy with
+// a different status, see reconcile. Copies are compared by when they were
+// saved, as recorded in the task. Tasks saved before that was recorded are
+// compared by the data they have: a resolved copy is newer, as resolving is
+// usually the last change to a task. Otherwise, the time the store last saved
+// each copy is used, if it can tell; git doesn't keep the mtime of files, so
+// it's a last resort.
+func isNewerCopy(store Store, a, b Task) (bool, error) {
+	if !a.Modified.Equal(b.Modified) {
+		return a.Modified.After(b.Modified), nil
+	}
+
+	aResolved := a.Status == STATUS_RESOLVED && !a.Resolved.IsZero()
+	bResolved := b.Status == STATUS_RESOLVED && !b.Resolved.IsZero()
+
+	if aResolved != bResolved {
+		return aResolved, nil
+	}
+
+	if store, ok := store.(ModTimeStore); ok {
+		aTime, err := store.ModTime(a.Status, a.UUID)
+		if err != nil {
+			return false, err
+		}
+
+		bTime, err := store.ModTime(b.Status, b.UUID)
+		if err != nil {
+			return false, err
+		}
+
+		if !aTime.Equal(bTime) {
+			return aTime.After(bTime), nil
+		}
 	}
 
-	return newTask
+	return false, nil
 }
 
-// LoadTask adds a task to the TaskSet, but only if it has a new uuid or no uuid.
+// unloadTask removes a task from the TaskSet.
+func (ts *TaskSet) unloadTask(uuid string) {
+	task := ts.tasksByUUID[uuid]
+
+	ts.tasks = slices.DeleteFunc(ts.tasks, func(t *Task) bool { return t == task })
+	delete(ts.tasksByUUID, uuid)
+
+	if ts.tasksByID[task.ID] == task {
+		delete(ts.tasksByID, task.ID)
+	}
+}
+
+// LoadTask adds a task to the TaskSet, if it has a new uuid or no uuid. The
+// error wraps ErrDuplicateTask if a task with the uuid is already loaded.
 // Return annotated task.
 func (ts *TaskSet) LoadTask(task Task) (Task, error) {
 	task.Normalise()
 
 	if task.UUID == "" {
-		task.UUID = MustGetUUID4String()
+		uuid, err := NewUUID4String()
+		if err != nil {
+			return Task{}, err
+		}
+
+		task.UUID = uuid
 	}
 
 	if err := task.Validate(); err != nil {
@@ -197,9 +372,7 @@ func (ts *TaskSet) LoadTask(task Task) (Task, error) {
 	}
 
 	if ts.tasksByUUID[task.UUID] != nil {
-		// load tasks, do not overwrite
-		// TODO ??? (maybe return a nil pointer instead?)
-		return Task{}, nil
+		return Task{}, fmt.Errorf("%w: %s", ErrDuplicateTask, task.UUID)
 	}
 
 	// remove ID if already taken
@@ -230,15 +403,8 @@ func (ts *TaskSet) LoadTask(task Task) (Task, error) {
 	return task, nil
 }
 
-// TODO maybe this is the place to check for invalid state transitions instead
-// of the main switch statement. Though, a future 3rdparty sync system could
-// need this to work regardless.
-func (ts *TaskSet) MustUpdateTask(task Task) {
-	if err := ts.UpdateTask(task); err != nil {
-		ExitFail(err.Error())
-	}
-}
-
+// UpdateTask replaces the task with the same UUID, after validating it and
+// the change of status, if any.
 func (ts *TaskSet) UpdateTask(task Task) error {
 	task.Normalise()
 
@@ -247,7 +413,7 @@ func (ts *TaskSet) UpdateTask(task Task) error {
 	}
 
 	if ts.tasksByUUID[task.UUID] == nil {
-		return errors.New("could not find given task to update by UUID")
+		return fmt.Errorf("%w: no task has UUID %s", ErrTaskNotFound, task.UUID)
 	}
 
 	if !IsValidPriority(task.Priority) {
@@ -257,12 +423,16 @@ func (ts *TaskSet) UpdateTask(task Task) error {
 	old := ts.tasksByUUID[task.UUID]
 
 	if old.Status != task.Status && !IsValidStateTransition(old.Status, task.Status) {
-		return fmt.Errorf("invalid state transition: %s -> %s", old.Status, task.Status)
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, old.Status, task.Status)
 	}
 
 	if old.Status != task.Status && task.Status == STATUS_RESOLVED &&
-		strings.Contains(task.Notes, "- [ ] ") {
-		return errors.New("refusing to resolve task with incomplete tasklist")
+		task.HasIncompleteSubtasks() {
+		return errors.New("refusing to resolve task with incomplete subtasks")
+	}
+
+	if err := ts.checkDependencyCycle(task); err != nil {
+		return err
 	}
 
 	if task.Status == STATUS_RESOLVED {
@@ -277,10 +447,99 @@ func (ts *TaskSet) UpdateTask(task Task) error {
 	// existing pointer must point to address of new task copied
 	*ts.tasksByUUID[task.UUID] = task
 
+	// resolving or changing the dependencies of a task can (un)block others
+	ts.updateBlocked()
+
+	return nil
+}
+
+// ApplyDependencies adds and removes the dependencies given by ID in the query
+// to a task. Call UpdateTask afterwards to check for cycles.
+func (ts *TaskSet) ApplyDependencies(task *Task, query Query) error {
+	for _, id := range query.Dependencies {
+		dep, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if !StrSliceContains(task.Dependencies, dep.UUID) {
+			task.Dependencies = append(task.Dependencies, dep.UUID)
+		}
+	}
+
+	for _, id := range query.AntiDependencies {
+		dep, err := ts.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		task.Dependencies = slices.DeleteFunc(task.Dependencies, func(uuid string) bool {
+			return uuid == dep.UUID
+		})
+	}
+
+	return nil
+}
+
+// checkDependencyCycle returns an error if the dependencies of the given task
+// lead back to itself. Dependencies that are not loaded (eg. resolved tasks)
+// cannot be part of a cycle.
+func (ts *TaskSet) checkDependencyCycle(task Task) error {
+	visited := make(map[string]bool)
+
+	var visit func(uuids []string) bool
+
+	visit = func(uuids []string) bool {
+		for _, uuid := range uuids {
+			if uuid == task.UUID {
+				return true
+			}
+
+			if visited[uuid] {
+				continue
+			}
+
+			visited[uuid] = true
+
+			if dep := ts.tasksByUUID[uuid]; dep != nil && visit(dep.Dependencies) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if visit(task.Dependencies) {
+		return fmt.Errorf("dependency cycle detected, task %s", task)
+	}
+
 	return nil
 }
 
+// updateBlocked derives the Blocked state of each task: a task is blocked if
+// any of its dependencies are loaded and not resolved. Dependencies that are
+// not loaded are resolved or deleted, so do not block.
+func (ts *TaskSet) updateBlocked() {
+	for _, task := range ts.tasks {
+		task.Blocked = false
+
+		for _, uuid := range task.Dependencies {
+			if dep := ts.tasksByUUID[uuid]; dep != nil && dep.Status != STATUS_RESOLVED && !dep.Deleted {
+				task.Blocked = true
+
+				break
+			}
+		}
+	}
+}
+
 func (ts *TaskSet) Filter(query Query) {
+	ts.searches = append(ts.searches, query.searches()...)
+
+	if len(query.Sort) > 0 {
+		ts.sortKeys = query.Sort
+	}
+
 	for _, task := range ts.tasks {
 		if !task.MatchesFilter(query) {
 			task.filtered = true
@@ -288,9 +547,10 @@ func (ts *TaskSet) Filter(query Query) {
 	}
 }
 
-func (ts *TaskSet) FilterByStatus(status string) {
+// FilterByStatus filters out tasks without any of the given statuses.
+func (ts *TaskSet) FilterByStatus(statuses ...string) {
 	for _, task := range ts.tasks {
-		if task.Status != status {
+		if !slices.Contains(statuses, task.Status) {
 			task.filtered = true
 		}
 	}
@@ -304,18 +564,11 @@ func (ts *TaskSet) FilterOrganised() {
 	}
 }
 
-func (ts *TaskSet) MustGetByID(id int) Task {
-	task, err := ts.GetByID(id)
-	if err != nil {
-		ExitFail(err.Error())
-	}
-
-	return task
-}
-
+// GetByID returns the unresolved task with the given ID. The error wraps
+// ErrTaskNotFound if there is none.
 func (ts *TaskSet) GetByID(id int) (Task, error) {
 	if ts.tasksByID[id] == nil {
-		return Task{}, fmt.Errorf("no open task with ID %v exists", id)
+		return Task{}, fmt.Errorf("%w: no open task has ID %d", ErrTaskNotFound, id)
 	}
 
 	return *ts.tasksByID[id], nil
@@ -353,45 +606,81 @@ func (ts *TaskSet) GetTags() map[string]bool {
 	return tagset
 }
 
-func (ts *TaskSet) GetProjects() []*Project {
-	projectsMap := make(map[string]*Project)
-
-	for _, task := range ts.AllTasks() {
-		name := task.Project
+// GetDelegates returns the people non-filtered tasks are delegated to.
+func (ts *TaskSet) GetDelegates() map[string]bool {
+	delegates := make(map[string]bool)
 
-		if name == "" {
-			continue
+	for _, task := range ts.Tasks() {
+		if task.DelegatedTo != "" {
+			delegates[task.DelegatedTo] = true
 		}
+	}
 
+	return delegates
+}
+
+// GetProjects returns every project with tasks or a record, and their
+// ancestors. Records of archived projects are included. Counts,
+// dates and priorities of a project are rolled up from its descendants.
+// Projects are ordered as a tree, depth first.
+func (ts *TaskSet) GetProjects() []*Project {
+	projectsMap := make(map[string]*Project)
+
+	getProject := func(name string) *Project {
 		if projectsMap[name] == nil {
 			projectsMap[name] = &Project{
-				Name:     name,
-				Priority: PRIORITY_LOW,
+				ProjectRecord: ts.projectRecords[name],
+				Name:          name,
+				Priority:      PRIORITY_LOW,
 			}
-		}
-
-		project := projectsMap[name]
 
-		project.Tasks++
+			if ancestors := ProjectAncestors(name); len(ancestors) > 0 {
+				projectsMap[name].Parent = ancestors[0]
+			}
 
-		if project.Created.IsZero() || task.Created.Before(project.Created) {
-			project.Created = task.Created
+			// archiving a project archives its descendants
+			projectsMap[name].Archived = isArchived(ts.projectRecords, name)
 		}
 
-		if task.Resolved.After(project.Resolved) {
-			project.Resolved = task.Resolved
-		}
+		return projectsMap[name]
+	}
 
-		if task.Status == STATUS_RESOLVED {
-			project.TasksResolved++
+	for _, task := range ts.AllTasks() {
+		if task.Project == "" {
+			continue
 		}
 
-		if task.Status == STATUS_ACTIVE {
-			project.Active = true
+		for _, name := range append([]string{task.Project}, ProjectAncestors(task.Project)...) {
+			project := getProject(name)
+
+			project.Tasks++
+
+			if project.Created.IsZero() || task.Created.Before(project.Created) {
+				project.Created = task.Created
+			}
+
+			if task.Resolved.After(project.Resolved) {
+				project.Resolved = task.Resolved
+			}
+
+			if task.Status == STATUS_RESOLVED {
+				project.TasksResolved++
+			}
+
+			if task.Status == STATUS_ACTIVE {
+				project.Active = true
+			}
+
+			if task.Status != STATUS_RESOLVED && task.Priority < project.Priority {
+				project.Priority = task.Priority
+			}
 		}
+	}
 
-		if task.Status != STATUS_RESOLVED && task.Priority < project.Priority {
-			project.Priority = task.Priority
+	// projects with a record but no tasks (yet)
+	for name := range ts.projectRecords {
+		for _, name := range append([]string{name}, ProjectAncestors(name)...) {
+			getProject(name)
 		}
 	}
 
@@ -403,7 +692,7 @@ func (ts *TaskSet) GetProjects() []*Project {
 		names = append(names, name)
 	}
 
-	sort.Strings(names)
+	slices.SortFunc(names, compareProjects)
 
 	for _, name := range names {
 		projects = append(projects, projectsMap[name])
@@ -412,6 +701,30 @@ func (ts *TaskSet) GetProjects() []*Project {
 	return projects
 }
 
+// ProjectDeadline returns the earliest deadline of the project or its
+// ancestors, and the project it belongs to. The deadline is zero if there is
+// none.
+func (ts *TaskSet) ProjectDeadline(project string) (string, time.Time) {
+	var (
+		source   string
+		deadline time.Time
+	)
+
+	if project == "" {
+		return source, deadline
+	}
+
+	for _, name := range append([]string{project}, ProjectAncestors(project)...) {
+		d := ts.projectRecords[name].Deadline
+		if !d.IsZero() && (deadline.IsZero() || d.Before(deadline)) {
+			source = name
+			deadline = d
+		}
+	}
+
+	return source, deadline
+}
+
 func (ts *TaskSet) NumTotal() int {
 	return len(ts.tasks)
 }
@@ -419,14 +732,23 @@ func (ts *TaskSet) NumTotal() int {
 // save pending changes to disk
 // TODO return files that have been added/deleted/modified/renamed so they can
 // be passed to git add for performance, instead of doing git add .
-func (ts *TaskSet) SavePendingChanges() {
-	ids := make(IdsMap, len(ts.Tasks()))
-
+func (ts *TaskSet) SavePendingChanges() error {
 	for _, task := range ts.tasks {
 		if task.WritePending {
-			task.SaveToDisk(ts.repoPath)
+			if err := ts.saveTask(task); err != nil {
+				return err
+			}
 		}
+	}
+
+	return ts.saveIds()
+}
+
+// saveIds saves the IDs of tasks to the ids file.
+func (ts *TaskSet) saveIds() error {
+	ids := make(IdsMap, len(ts.tasks))
 
+	for _, task := range ts.tasks {
 		if task.ID > 0 {
 			ids[task.UUID] = task.ID
 		}
@@ -439,7 +761,28 @@ func (ts *TaskSet) SavePendingChanges() {
 	// possible for every ID to change. Therefore, tasks must retain their IDs
 	// locally. This replaced a system where tasks recorded their IDs, which
 	// can create merge conflicts in some (uncommon) cases.
-	ids.Save(ts.idsFilePath)
+	return ids.Save(ts.idsFilePath)
+}
+
+// saveTask writes the task to the store, or removes it if deleted.
+func (ts *TaskSet) saveTask(task *Task) error {
+	var err error
+
+	if task.Deleted {
+		err = ts.store.Delete(task.UUID)
+	} else {
+		task.Modified = time.Now()
+		err = ts.store.Save(*task)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// save should be idempotent
+	task.WritePending = false
+
+	return nil
 }
 
 type SortByDirection string
@@ -0,0 +1,128 @@
+package dstask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTaskSet() *TaskSet {
+	return &TaskSet{
+		tasksByID:   make(map[int]*Task),
+		tasksByUUID: make(map[string]*Task),
+	}
+}
+
+// mustLoadTask loads a task, failing the test on error.
+func mustLoadTask(t *testing.T, ts *TaskSet, task Task) Task {
+	t.Helper()
+
+	task, err := ts.LoadTask(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+func mustGetByID(t *testing.T, ts *TaskSet, id int) Task {
+	t.Helper()
+
+	task, err := ts.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+func TestPromoteDeferred(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	ts := newTestTaskSet()
+
+	due := mustLoadTask(t, ts, Task{
+		Status:  STATUS_DEFERRED,
+		Summary: "wake up",
+		Created: now.AddDate(0, 0, -7),
+		Wait:    startOfDay(now),
+	})
+
+	later := mustLoadTask(t, ts, Task{
+		Status:  STATUS_DEFERRED,
+		Summary: "sleep in",
+		Wait:    startOfDay(now).AddDate(0, 0, 1),
+	})
+
+	assert.Equal(t, 1, ts.PromoteDeferred(now))
+
+	task := ts.tasksByUUID[due.UUID]
+	assert.Equal(t, STATUS_PENDING, task.Status)
+	assert.True(t, task.Wait.IsZero())
+	assert.False(t, task.WritePending, "saved with its next change")
+
+	assert.Equal(t, STATUS_DEFERRED, ts.tasksByUUID[later.UUID].Status)
+}
+
+func TestDependencies(t *testing.T) {
+	ts := newTestTaskSet()
+
+	a := mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "a"})
+	b := mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "b"})
+	c := mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "c"})
+
+	// a depends on b, b depends on c
+	assert.NoError(t, ts.ApplyDependencies(&a, Query{Dependencies: []int{b.ID}}))
+	assert.NoError(t, ts.UpdateTask(a))
+	assert.NoError(t, ts.ApplyDependencies(&b, Query{Dependencies: []int{c.ID}}))
+	assert.NoError(t, ts.UpdateTask(b))
+
+	assert.True(t, ts.tasksByUUID[a.UUID].Blocked)
+	assert.True(t, ts.tasksByUUID[b.UUID].Blocked)
+	assert.False(t, ts.tasksByUUID[c.UUID].Blocked)
+
+	// c depending on a would be a cycle
+	cycle := c
+	assert.NoError(t, ts.ApplyDependencies(&cycle, Query{Dependencies: []int{a.ID}}))
+	assert.Error(t, ts.UpdateTask(cycle))
+	assert.Empty(t, ts.tasksByUUID[c.UUID].Dependencies, "failed update has no effect")
+
+	// as would depending on itself
+	self := c
+	self.Dependencies = []string{c.UUID}
+	assert.Error(t, ts.UpdateTask(self))
+
+	// resolving c unblocks b
+	c.Status = STATUS_RESOLVED
+	assert.NoError(t, ts.UpdateTask(c))
+	assert.False(t, ts.tasksByUUID[b.UUID].Blocked)
+	assert.True(t, ts.tasksByUUID[a.UUID].Blocked)
+
+	// removing the dependency unblocks a
+	b = mustGetByID(t, ts, b.ID)
+	a = mustGetByID(t, ts, a.ID)
+	assert.NoError(t, ts.ApplyDependencies(&a, Query{AntiDependencies: []int{b.ID}}))
+	assert.NoError(t, ts.UpdateTask(a))
+	assert.False(t, ts.tasksByUUID[a.UUID].Blocked)
+}
+
+func TestTaskSetErrors(t *testing.T) {
+	ts := newTestTaskSet()
+
+	task := mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "a"})
+
+	_, err := ts.GetByID(task.ID + 1)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	unknown := task
+	unknown.UUID = "3d64c98f-459c-4a5d-b003-30b9727a7e9c"
+	assert.ErrorIs(t, ts.UpdateTask(unknown), ErrTaskNotFound)
+
+	task.Status = STATUS_RESOLVED
+	assert.NoError(t, ts.UpdateTask(task))
+
+	task.Status = STATUS_ACTIVE
+	err = ts.UpdateTask(task)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.EqualError(t, err, "invalid state transition: resolved -> active")
+}
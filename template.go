@@ -0,0 +1,70 @@
+package dstask
+
+// custom output of listings, rendered with text/template for each task,
+// project etc., eg. --template '{{.ID}} {{.Summary}}'
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// the output format of listings with --template, which is not one of FORMATS
+const FORMAT_TEMPLATE = "template"
+
+// the template of each item of listings, set by the --template option
+var TEMPLATE *template.Template
+
+// functions available to output templates, in addition to those of
+// text/template
+var TEMPLATE_FUNCS = template.FuncMap{
+	// eg. {{join .Tags ","}}
+	"join": strings.Join,
+	// eg. {{date .Due}}, as in the task list. Empty if not set.
+	"date": formatDate,
+}
+
+// ParseOutputTemplate returns the template named in the config file, or else
+// parses text as a template.
+func ParseOutputTemplate(conf Config, text string) (*template.Template, error) {
+	name := "template"
+
+	if named, ok := conf.Templates[text]; ok {
+		name, text = text, named
+	}
+
+	tmpl, err := template.New(name).Funcs(TEMPLATE_FUNCS).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// writeTemplate renders the template for each item, one per line. Items the
+// template renders as nothing are left out.
+func writeTemplate[T any](w *bytes.Buffer, tmpl *template.Template, items []T) error {
+	var line bytes.Buffer
+
+	for i := range items {
+		line.Reset()
+
+		// a pointer, so that methods such as .LongSummary can be called
+		if err := tmpl.Execute(&line, &items[i]); err != nil {
+			return err
+		}
+
+		if line.Len() == 0 {
+			continue
+		}
+
+		if !bytes.HasSuffix(line.Bytes(), []byte("\n")) {
+			line.WriteByte('\n')
+		}
+
+		w.Write(line.Bytes())
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package dstask
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputTemplate(t *testing.T) {
+	conf := Config{Templates: map[string]string{"bar": "{{.ID}}: {{.Summary}}"}}
+	task := Task{ID: 3, Summary: "a"}
+
+	var w bytes.Buffer
+
+	tmpl, err := ParseOutputTemplate(conf, "bar")
+	assert.NoError(t, err)
+	assert.NoError(t, tmpl.Execute(&w, &task))
+	assert.Equal(t, "3: a", w.String())
+
+	w.Reset()
+
+	tmpl, err = ParseOutputTemplate(conf, "{{.Summary}} {{join .Tags \",\"}}")
+	assert.NoError(t, err)
+	assert.NoError(t, tmpl.Execute(&w, &Task{Summary: "a", Tags: []string{"x", "y"}}))
+	assert.Equal(t, "a x,y", w.String())
+
+	_, err = ParseOutputTemplate(conf, "{{.Summary")
+	assert.Error(t, err)
+}
+
+func TestWriteTemplate(t *testing.T) {
+	tmpl, err := ParseOutputTemplate(Config{}, "{{if .Due.IsZero}}{{else}}{{.ID}} {{date .Due}}{{end}}")
+	assert.NoError(t, err)
+
+	tasks := []Task{
+		{ID: 1, Due: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: 2},
+		{ID: 3, Due: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	var w bytes.Buffer
+
+	// one per line, leaving out tasks rendered as nothing
+	assert.NoError(t, writeTemplate(&w, tmpl, tasks))
+	assert.Equal(t, "1 Fri 2 Jan 2026\n3 Sat 3 Jan 2026\n", w.String())
+
+	// methods with pointer receivers can be called
+	tmpl, err = ParseOutputTemplate(Config{}, "{{.LongSummary}}\n")
+	assert.NoError(t, err)
+
+	w.Reset()
+	assert.NoError(t, writeTemplate(&w, tmpl, []Task{{Summary: "a"}}))
+	assert.Equal(t, "a\n", w.String())
+
+	tmpl, err = ParseOutputTemplate(Config{}, "{{.Frobnicate}}")
+	assert.NoError(t, err)
+	assert.Error(t, writeTemplate(&w, tmpl, tasks))
+}
@@ -0,0 +1,225 @@
+package dstask
+
+// themes are sets of colours and ANSI modes of the task list and messages,
+// selected with the theme setting of the config file.
+
+const (
+	THEME_DARK_256   = "dark-256"
+	THEME_LIGHT_256  = "light-256"
+	THEME_16         = "16-colour"
+	THEME_MONOCHROME = "monochrome"
+
+	// leaves the colour of the terminal as it is
+	COLOUR_NONE = -1
+
+	// ANSI modes
+	MODE_BOLD      = 1
+	MODE_FAINT     = 2
+	MODE_ITALIC    = 3
+	MODE_UNDERLINE = 4
+	MODE_REVERSE   = 7
+)
+
+// the current theme, set by Theme.apply. Colours are of the palette of the
+// theme, or COLOUR_NONE.
+var (
+	PALETTE = 256
+
+	MODE_HEADER            int
+	MODE_DEFAULT           int
+	MODE_ACTIVE            int
+	MODE_PAUSED            int
+	MODE_PRIORITY_CRITICAL int
+	MODE_PRIORITY_HIGH     int
+	MODE_PRIORITY_LOW      int
+	MODE_OVERDUE           int
+
+	FG_DEFAULT                  int
+	BG_DEFAULT_1                int
+	BG_DEFAULT_2                int
+	FG_ACTIVE                   int
+	BG_ACTIVE                   int
+	BG_PAUSED                   int // task that has been started then stopped
+	FG_PRIORITY_CRITICAL        int
+	FG_PRIORITY_HIGH            int
+	FG_PRIORITY_NORMAL          int
+	FG_PRIORITY_LOW             int
+	FG_ACTIVE_PRIORITY_CRITICAL int
+	FG_ACTIVE_PRIORITY_HIGH     int
+	FG_ACTIVE_PRIORITY_LOW      int
+	FG_OVERDUE                  int // unresolved task past its due date
+	FG_ACTIVE_OVERDUE           int
+	FG_NOTE                     int
+	FG_FADED                    int // notes of a task and git output
+	FG_CONTEXT                  int // description of the active context
+	FG_ERROR                    int
+)
+
+// colours of the theme, by lower case name. They can be changed in the config
+// file.
+var themeColours = map[string]*int{
+	"fg_default":                  &FG_DEFAULT,
+	"bg_default_1":                &BG_DEFAULT_1,
+	"bg_default_2":                &BG_DEFAULT_2,
+	"fg_active":                   &FG_ACTIVE,
+	"bg_active":                   &BG_ACTIVE,
+	"bg_paused":                   &BG_PAUSED,
+	"fg_priority_critical":        &FG_PRIORITY_CRITICAL,
+	"fg_priority_high":            &FG_PRIORITY_HIGH,
+	"fg_priority_normal":          &FG_PRIORITY_NORMAL,
+	"fg_priority_low":             &FG_PRIORITY_LOW,
+	"fg_active_priority_critical": &FG_ACTIVE_PRIORITY_CRITICAL,
+	"fg_active_priority_high":     &FG_ACTIVE_PRIORITY_HIGH,
+	"fg_active_priority_low":      &FG_ACTIVE_PRIORITY_LOW,
+	"fg_overdue":                  &FG_OVERDUE,
+	"fg_active_overdue":           &FG_ACTIVE_OVERDUE,
+	"fg_note":                     &FG_NOTE,
+	"fg_faded":                    &FG_FADED,
+	"fg_context":                  &FG_CONTEXT,
+	"fg_error":                    &FG_ERROR,
+}
+
+// modes of the theme, by lower case name
+var themeModes = map[string]*int{
+	"mode_header":            &MODE_HEADER,
+	"mode_default":           &MODE_DEFAULT,
+	"mode_active":            &MODE_ACTIVE,
+	"mode_paused":            &MODE_PAUSED,
+	"mode_priority_critical": &MODE_PRIORITY_CRITICAL,
+	"mode_priority_high":     &MODE_PRIORITY_HIGH,
+	"mode_priority_low":      &MODE_PRIORITY_LOW,
+	"mode_overdue":           &MODE_OVERDUE,
+}
+
+type Theme struct {
+	// 256 for the xterm 256-colour palette, or 16 for its first 16 colours,
+	// which nearly every terminal has
+	Palette int
+	// by name, see themeColours. Missing colours are COLOUR_NONE.
+	Colours map[string]int
+	// by name, see themeModes. Missing modes are 0, the default.
+	Modes map[string]int
+}
+
+var THEMES = map[string]Theme{
+	// loosely based on https://github.com/GothenburgBitFactory/taskwarrior/blob/2.6.0/doc/rc/dark-256.theme
+	THEME_DARK_256: {
+		Palette: 256,
+		Colours: map[string]int{
+			"fg_default":                  250,
+			"bg_default_1":                233,
+			"bg_default_2":                232,
+			"fg_active":                   233,
+			"bg_active":                   250,
+			"bg_paused":                   236,
+			"fg_priority_critical":        160,
+			"fg_priority_high":            166,
+			"fg_priority_normal":          250,
+			"fg_priority_low":             245,
+			"fg_active_priority_critical": 124,
+			"fg_active_priority_high":     130,
+			"fg_active_priority_low":      238,
+			"fg_overdue":                  201,
+			"fg_active_overdue":           127,
+			"fg_note":                     240,
+			"fg_faded":                    245,
+			"fg_context":                  3,
+			"fg_error":                    1,
+		},
+		Modes: map[string]int{
+			"mode_header": MODE_UNDERLINE,
+		},
+	},
+	// dark-256 inverted, for terminals with a light background
+	THEME_LIGHT_256: {
+		Palette: 256,
+		Colours: map[string]int{
+			"fg_default":                  235,
+			"bg_default_1":                255,
+			"bg_default_2":                254,
+			"fg_active":                   255,
+			"bg_active":                   238,
+			"bg_paused":                   251,
+			"fg_priority_critical":        160,
+			"fg_priority_high":            166,
+			"fg_priority_normal":          235,
+			"fg_priority_low":             244,
+			"fg_active_priority_critical": 210,
+			"fg_active_priority_high":     215,
+			"fg_active_priority_low":      248,
+			"fg_overdue":                  164,
+			"fg_active_overdue":           213,
+			"fg_note":                     247,
+			"fg_faded":                    243,
+			"fg_context":                  94,
+			"fg_error":                    124,
+		},
+		Modes: map[string]int{
+			"mode_header": MODE_UNDERLINE,
+		},
+	},
+	// the colours of the terminal, whatever its background. Active tasks are
+	// shown in reverse video.
+	THEME_16: {
+		Palette: 16,
+		Colours: map[string]int{
+			"fg_priority_critical":        9,
+			"fg_priority_high":            3,
+			"fg_priority_low":             8,
+			"fg_active_priority_critical": 9,
+			"fg_active_priority_high":     3,
+			"fg_active_priority_low":      8,
+			"fg_overdue":                  5,
+			"fg_active_overdue":           5,
+			"fg_note":                     8,
+			"fg_faded":                    8,
+			"fg_context":                  3,
+			"fg_error":                    1,
+		},
+		Modes: map[string]int{
+			"mode_header": MODE_UNDERLINE,
+			"mode_active": MODE_REVERSE,
+			"mode_paused": MODE_BOLD,
+		},
+	},
+	// no colours at all, for terminals without them or NO_COLOR
+	THEME_MONOCHROME: {
+		Palette: 16,
+		Modes: map[string]int{
+			"mode_header":            MODE_UNDERLINE,
+			"mode_active":            MODE_REVERSE,
+			"mode_paused":            MODE_ITALIC,
+			"mode_priority_critical": MODE_BOLD,
+			"mode_priority_low":      MODE_FAINT,
+			"mode_overdue":           MODE_BOLD,
+		},
+	},
+}
+
+const DEFAULT_THEME = THEME_DARK_256
+
+// until the config is loaded, see Config.ApplyDisplaySettings
+func init() {
+	if noColorSet() {
+		THEMES[THEME_MONOCHROME].apply()
+	} else {
+		THEMES[DEFAULT_THEME].apply()
+	}
+}
+
+// apply makes the theme the current theme.
+func (theme Theme) apply() {
+	PALETTE = theme.Palette
+
+	for name, colour := range themeColours {
+		if c, ok := theme.Colours[name]; ok {
+			*colour = c
+		} else {
+			*colour = COLOUR_NONE
+		}
+	}
+
+	for name, mode := range themeModes {
+		*mode = theme.Modes[name]
+	}
+}
@@ -0,0 +1,189 @@
+package dstask
+
+// changes to several tasks, written and committed together. This avoids a
+// commit per task when a command is given many IDs, or a whole context.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tx stages changes to the tasks of a TaskSet until they are committed with
+// Commit. Rollback undoes the changes instead, eg. if one of them fails
+// validation. It's safe to defer Rollback, as it does nothing once committed:
+//
+//	tx := ts.Begin(CMD_DONE)
+//	defer tx.Rollback()
+type Tx struct {
+	ts  *TaskSet
+	cmd string
+
+	// copy of each task of the TaskSet when the transaction began, in order.
+	// Tasks after these are new.
+	before []Task
+
+	// tasks changed, with a message for each, as given
+	tasks    []Task
+	messages []string
+
+	// Comment is added to the commit message, after a blank line.
+	Comment string
+
+	done bool
+}
+
+// Begin starts a transaction for the command, which chooses the template of
+// the commit message, see CommitMessage.
+func (ts *TaskSet) Begin(cmd string) *Tx {
+	tx := &Tx{
+		ts:     ts,
+		cmd:    cmd,
+		before: make([]Task, len(ts.tasks)),
+	}
+
+	for i, task := range ts.tasks {
+		tx.before[i] = *task
+	}
+
+	return tx
+}
+
+// Update is like TaskSet.UpdateTask, and stages the change. The message
+// describes it, eg. "Resolved %s", for the commit message.
+func (tx *Tx) Update(task Task, format string, a ...any) error {
+	if tx.done {
+		return errors.New("transaction already finished")
+	}
+
+	if err := tx.ts.UpdateTask(task); err != nil {
+		return err
+	}
+
+	tx.tasks = append(tx.tasks, task)
+	tx.messages = append(tx.messages, fmt.Sprintf(format, a...))
+
+	return nil
+}
+
+// Len is the number of changes staged.
+func (tx *Tx) Len() int {
+	return len(tx.messages)
+}
+
+// Commit writes the staged changes and commits them in a single commit. The
+// commit message is that of the change if there's only one; otherwise it's
+// summary, formatted with the number of changes, eg. "Resolved %d tasks",
+// followed by the message of each change. If writing fails, tasks already
+// written are restored, and the transaction is rolled back.
+func (tx *Tx) Commit(conf Config, summary string) error {
+	if tx.done {
+		return errors.New("transaction already finished")
+	}
+
+	if len(tx.messages) == 0 {
+		tx.done = true
+
+		return nil
+	}
+
+	if err := tx.write(); err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	tx.done = true
+
+	data := CommitMessage{
+		Cmd:     tx.cmd,
+		Message: tx.messages[0],
+		Tasks:   tx.tasks,
+	}
+
+	if len(tx.tasks) == 1 {
+		data.Task = tx.tasks[0]
+	} else {
+		data.Message = fmt.Sprintf(summary, len(tx.messages)) + "\n\n" + strings.Join(tx.messages, "\n")
+	}
+
+	if tx.Comment != "" {
+		data.Message += "\n\n" + tx.Comment
+	}
+
+	return conf.commit(data)
+}
+
+// write saves the tasks that have changed, and the IDs. If a task can't be
+// saved, those already saved are restored.
+func (tx *Tx) write() error {
+	ts := tx.ts
+
+	var written []int
+
+	for i, task := range ts.tasks {
+		if !task.WritePending {
+			continue
+		}
+
+		if err := ts.saveTask(task); err != nil {
+			if rollbackErr := tx.unwrite(written); rollbackErr != nil {
+				return fmt.Errorf("%w, and failed to restore tasks: %w", err, rollbackErr)
+			}
+
+			return err
+		}
+
+		written = append(written, i)
+	}
+
+	return ts.saveIds()
+}
+
+// unwrite restores the tasks, by index, to the store as they were before the
+// transaction. New tasks are removed.
+func (tx *Tx) unwrite(written []int) error {
+	var errs []error
+
+	for _, i := range written {
+		var err error
+
+		if i < len(tx.before) {
+			err = tx.ts.store.Save(tx.before[i])
+		} else {
+			err = tx.ts.store.Delete(tx.ts.tasks[i].UUID)
+		}
+
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Rollback restores the TaskSet to how it was when the transaction began,
+// unless committed.
+func (tx *Tx) Rollback() {
+	if tx.done {
+		return
+	}
+
+	tx.done = true
+
+	ts := tx.ts
+
+	for _, task := range ts.tasks[len(tx.before):] {
+		delete(ts.tasksByUUID, task.UUID)
+
+		if ts.tasksByID[task.ID] == task {
+			delete(ts.tasksByID, task.ID)
+		}
+	}
+
+	ts.tasks = ts.tasks[:len(tx.before)]
+
+	for i, task := range ts.tasks {
+		*task = tx.before[i]
+	}
+
+	ts.updateBlocked()
+}
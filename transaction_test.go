@@ -0,0 +1,143 @@
+package dstask
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore fails to save a task, by UUID.
+type failingStore struct {
+	*MemoryStore
+	uuid string
+}
+
+func (s failingStore) Save(t Task) error {
+	if t.UUID == s.uuid {
+		return errors.New("disk full")
+	}
+
+	return s.MemoryStore.Save(t)
+}
+
+// newStoredTaskSet saves the tasks to the store, and loads a TaskSet from it.
+func newStoredTaskSet(t *testing.T, store Store, tasks ...Task) *TaskSet {
+	t.Helper()
+
+	for _, task := range tasks {
+		assert.NoError(t, store.Save(task))
+	}
+
+	repoPath := t.TempDir()
+
+	ts, err := LoadTaskSet(store, repoPath, filepath.Join(repoPath, "ids.bin"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ts
+}
+
+// mustGetByUUID returns a copy of the task, failing the test if not loaded.
+func mustGetByUUID(t *testing.T, ts *TaskSet, uuid string) Task {
+	t.Helper()
+
+	task := ts.tasksByUUID[uuid]
+	if task == nil {
+		t.Fatalf("task %s not loaded", uuid)
+	}
+
+	return *task
+}
+
+var txCreated = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+var txTasks = []Task{
+	{UUID: "7a1b3c9e-2f4d-4e6a-8b0c-1d2e3f4a5b01", Status: STATUS_PENDING, Summary: "a", Created: txCreated},
+	{UUID: "7a1b3c9e-2f4d-4e6a-8b0c-1d2e3f4a5b02", Status: STATUS_PENDING, Summary: "b", Created: txCreated},
+	{
+		UUID:     "7a1b3c9e-2f4d-4e6a-8b0c-1d2e3f4a5b03",
+		Status:   STATUS_PENDING,
+		Summary:  "c",
+		Created:  txCreated,
+		Subtasks: []SubTask{{Summary: "first"}},
+	},
+}
+
+func TestTxRollback(t *testing.T) {
+	store := NewMemoryStore()
+	ts := newStoredTaskSet(t, store, txTasks...)
+
+	tx := ts.Begin(CMD_DONE)
+
+	a := mustGetByUUID(t, ts, txTasks[0].UUID)
+	a.Status = STATUS_RESOLVED
+	assert.NoError(t, tx.Update(a, "Resolved %s", a))
+
+	c := mustGetByUUID(t, ts, txTasks[2].UUID)
+	c.Status = STATUS_RESOLVED
+	assert.ErrorContains(t, tx.Update(c, "Resolved %s", c), "incomplete subtasks")
+	assert.Equal(t, 1, tx.Len())
+
+	tx.Rollback()
+
+	a = mustGetByUUID(t, ts, txTasks[0].UUID)
+	assert.Equal(t, STATUS_PENDING, a.Status)
+	assert.False(t, a.WritePending)
+	assert.Equal(t, a, mustGetByID(t, ts, a.ID), "ID is kept")
+
+	_, err := store.Load(STATUS_RESOLVED, a.UUID)
+	assert.ErrorIs(t, err, ErrTaskNotFound, "nothing written")
+
+	assert.Error(t, tx.Update(a, "Resolved %s", a), "finished")
+}
+
+func TestTxRollbackNewTask(t *testing.T) {
+	ts := newStoredTaskSet(t, NewMemoryStore(), txTasks...)
+
+	tx := ts.Begin(CMD_ADD)
+
+	task, err := ts.LoadTask(Task{Status: STATUS_PENDING, Summary: "d"})
+	assert.NoError(t, err)
+
+	tx.Rollback()
+
+	assert.Len(t, ts.AllTasks(), len(txTasks))
+
+	assert.Nil(t, ts.tasksByUUID[task.UUID])
+
+	_, err = ts.GetByID(task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestTxWriteRestores(t *testing.T) {
+	store := failingStore{NewMemoryStore(), ""}
+	ts := newStoredTaskSet(t, store, txTasks...)
+	store.uuid = txTasks[1].UUID
+	ts.store = store
+
+	tx := ts.Begin(CMD_MODIFY)
+
+	for _, task := range ts.AllTasks() {
+		task.Summary += " modified"
+		assert.NoError(t, tx.Update(task, "Modified %s", task))
+	}
+
+	assert.ErrorContains(t, tx.write(), "disk full")
+
+	for _, task := range txTasks {
+		stored, err := store.Load(STATUS_PENDING, task.UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, task.Summary, stored.Summary, "restored")
+	}
+}
+
+func TestTxCommitNothing(t *testing.T) {
+	ts := newStoredTaskSet(t, NewMemoryStore(), txTasks...)
+
+	tx := ts.Begin(CMD_MODIFY)
+	assert.NoError(t, tx.Commit(Config{}, "Modified %d tasks"), "no changes, so no commit")
+}
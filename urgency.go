@@ -0,0 +1,154 @@
+package dstask
+
+// urgency is a score of how much a task needs attention, in the style of
+// taskwarrior. It is the sum of the coefficients of the attributes of a task,
+// some scaled by how much they apply.
+
+import (
+	"sort"
+	"time"
+)
+
+// Urgency is the set of coefficients of the urgency score, configurable in
+// the [urgency] table of the config file.
+type Urgency struct {
+	PriorityCritical float64 `toml:"priority_critical"`
+	PriorityHigh     float64 `toml:"priority_high"`
+	PriorityNormal   float64 `toml:"priority_normal"`
+	PriorityLow      float64 `toml:"priority_low"`
+	// scaled from 0.2, two weeks or more before the due date, to 1.0, a week
+	// or more overdue
+	Due float64 `toml:"due"`
+	// scaled from 0.0 when created to 1.0 a year later
+	Age    float64 `toml:"age"`
+	Active float64 `toml:"active"`
+	// has unresolved dependencies
+	Blocked float64 `toml:"blocked"`
+	// an unresolved task depends on it
+	Blocking float64 `toml:"blocking"`
+	// scaled from 0.8 with one tag to 1.0 with three or more
+	Tags float64 `toml:"tags"`
+	// added for each tag of the task, by tag
+	Tag map[string]float64 `toml:"tag"`
+}
+
+// DefaultUrgency returns the default coefficients, based on taskwarrior.
+func DefaultUrgency() Urgency {
+	return Urgency{
+		PriorityCritical: 9.0,
+		PriorityHigh:     6.0,
+		PriorityNormal:   3.9,
+		PriorityLow:      1.8,
+		Due:              12.0,
+		Age:              2.0,
+		Active:           4.0,
+		Blocked:          -5.0,
+		Blocking:         8.0,
+		Tags:             1.0,
+		Tag:              map[string]float64{},
+	}
+}
+
+// Score returns the urgency of a task at the given time.
+func (u Urgency) Score(t *Task, blocking bool, now time.Time) float64 {
+	var score float64
+
+	switch t.Priority {
+	case PRIORITY_CRITICAL:
+		score += u.PriorityCritical
+	case PRIORITY_HIGH:
+		score += u.PriorityHigh
+	case PRIORITY_NORMAL:
+		score += u.PriorityNormal
+	case PRIORITY_LOW:
+		score += u.PriorityLow
+	}
+
+	if !t.Due.IsZero() {
+		score += u.Due * dueFactor(now.Sub(t.Due))
+	}
+
+	if !t.Created.IsZero() {
+		score += u.Age * min(now.Sub(t.Created).Hours()/24/365, 1.0)
+	}
+
+	if t.Status == STATUS_ACTIVE {
+		score += u.Active
+	}
+
+	if t.Blocked {
+		score += u.Blocked
+	}
+
+	if blocking {
+		score += u.Blocking
+	}
+
+	switch len(t.Tags) {
+	case 0:
+	case 1:
+		score += u.Tags * 0.8
+	case 2:
+		score += u.Tags * 0.9
+	default:
+		score += u.Tags
+	}
+
+	for _, tag := range t.Tags {
+		score += u.Tag[tag]
+	}
+
+	return score
+}
+
+// dueFactor scales linearly with how overdue a task is, from 0.2 at 14 days
+// before the due date to 1.0 at 7 days after.
+func dueFactor(overdue time.Duration) float64 {
+	days := overdue.Hours() / 24
+
+	switch {
+	case days >= 7:
+		return 1.0
+	case days >= -14:
+		return (days+14)*0.8/21 + 0.2
+	default:
+		return 0.2
+	}
+}
+
+// UpdateUrgency scores every task of the TaskSet with the given coefficients.
+func (ts *TaskSet) UpdateUrgency(u Urgency, now time.Time) {
+	// tasks that unresolved tasks depend on
+	blocking := make(map[string]bool)
+
+	for _, task := range ts.tasks {
+		if task.Status == STATUS_RESOLVED {
+			continue
+		}
+
+		for _, uuid := range task.Dependencies {
+			blocking[uuid] = true
+		}
+	}
+
+	for _, task := range ts.tasks {
+		task.Urgency = u.Score(task, blocking[task.UUID], now)
+	}
+}
+
+func (ts *TaskSet) SortByUrgency(dir SortByDirection) {
+	switch dir {
+	case Ascending:
+		// least urgent first
+		sort.SliceStable(
+			ts.tasks,
+			func(i, j int) bool { return ts.tasks[i].Urgency < ts.tasks[j].Urgency },
+		)
+	case Descending:
+		// most urgent first
+		sort.SliceStable(
+			ts.tasks,
+			func(i, j int) bool { return ts.tasks[i].Urgency > ts.tasks[j].Urgency },
+		)
+	}
+}
@@ -0,0 +1,86 @@
+package dstask
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUrgencyScore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	u := DefaultUrgency()
+
+	assert.InDelta(t, 6.0, u.Score(&Task{Priority: PRIORITY_HIGH}, false, now), 0.001)
+	assert.InDelta(t, 3.9+4.0, u.Score(&Task{Priority: PRIORITY_NORMAL, Status: STATUS_ACTIVE}, false, now), 0.001)
+	assert.InDelta(t, 1.8+2.0, u.Score(&Task{Priority: PRIORITY_LOW, Created: now.AddDate(0, 0, -365)}, false, now), 0.01)
+	assert.InDelta(t, 3.9-5.0+8.0, u.Score(&Task{Priority: PRIORITY_NORMAL, Blocked: true}, true, now), 0.001)
+	assert.InDelta(t, 0.8, u.Score(&Task{Tags: []string{"a"}}, false, now), 0.001)
+	assert.InDelta(t, 1.0, u.Score(&Task{Tags: []string{"a", "b", "c", "d"}}, false, now), 0.001)
+
+	u.Tag["next"] = 15.0
+	assert.InDelta(t, 0.8+15.0, u.Score(&Task{Tags: []string{"next"}}, false, now), 0.001)
+
+	// due today is more urgent than due next week
+	dueToday := u.Score(&Task{Due: now}, false, now)
+	dueNextWeek := u.Score(&Task{Due: now.AddDate(0, 0, 7)}, false, now)
+	assert.Greater(t, dueToday, dueNextWeek)
+}
+
+func TestDueFactor(t *testing.T) {
+	day := 24 * time.Hour
+
+	assert.InDelta(t, 0.2, dueFactor(-30*day), 0.001)
+	assert.InDelta(t, 0.2, dueFactor(-14*day), 0.001)
+	assert.InDelta(t, 1.0, dueFactor(7*day), 0.001)
+	assert.InDelta(t, 1.0, dueFactor(30*day), 0.001)
+	assert.InDelta(t, 0.2+14*0.8/21, dueFactor(0), 0.001)
+}
+
+func TestUpdateUrgencyBlocking(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	ts := newTestTaskSet()
+
+	dependency := mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "a", Priority: PRIORITY_LOW, Created: now})
+	mustLoadTask(t, ts, Task{Status: STATUS_PENDING, Summary: "b", Priority: PRIORITY_LOW, Created: now, Dependencies: []string{dependency.UUID}})
+	ts.updateBlocked()
+
+	ts.UpdateUrgency(DefaultUrgency(), now)
+	ts.SortByUrgency(Descending)
+
+	tasks := ts.Tasks()
+	assert.Equal(t, "a", tasks[0].Summary)
+	assert.InDelta(t, 1.8+8.0, tasks[0].Urgency, 0.001)
+	assert.InDelta(t, 1.8-5.0, tasks[1].Urgency, 0.001)
+}
+
+func TestConfigFileUrgency(t *testing.T) {
+	dir := t.TempDir()
+	conf := Config{
+		ConfigFile: filepath.Join(dir, "dstaskrc"),
+		Urgency:    DefaultUrgency(),
+	}
+
+	// a missing config file is fine
+	assert.NoError(t, conf.loadConfigFile())
+
+	err := os.WriteFile(conf.ConfigFile, []byte(`
+[urgency]
+due = 20.0
+
+[urgency.tag]
+next = 15.0
+`), 0o600)
+	assert.NoError(t, err)
+
+	assert.NoError(t, conf.loadConfigFile())
+	assert.Equal(t, 20.0, conf.Urgency.Due)
+	assert.Equal(t, 15.0, conf.Urgency.Tag["next"])
+	assert.Equal(t, 6.0, conf.Urgency.PriorityHigh, "defaults are kept")
+
+	err = os.WriteFile(conf.ConfigFile, []byte("[urgency]\ndeu = 20.0\n"), 0o600)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, conf.loadConfigFile(), "urgency.deu")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strings"
@@ -16,38 +17,41 @@ import (
 	"github.com/mattn/go-isatty"
 )
 
+// ExitFail prints an error and exits the program. It is for commands, eg.
+// cmd/dstask; the package returns errors.
 func ExitFail(format string, a ...any) {
-	fmt.Fprintf(os.Stderr, "\033[31m"+format+"\033[0m\n", a...)
+	fmt.Fprintln(os.Stderr, paint(os.Stderr, FG_ERROR, fmt.Sprintf(format, a...)))
 	os.Exit(1)
 }
 
-func ConfirmOrAbort(format string, a ...any) {
+// ConfirmOrAbort asks the user to confirm, returning ErrAborted unless they
+// do.
+func ConfirmOrAbort(format string, a ...any) error {
 	fmt.Fprintf(os.Stderr, format+" [y/n] ", a...)
 
 	reader := bufio.NewReader(os.Stdin)
 
 	input, err := reader.ReadString('\n')
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to read confirmation: %w", err)
 	}
 
 	// Normalize input: remove CR/LF/whitespace and compare in lowercase
 	normalized := strings.ToLower(strings.TrimSpace(input))
 	if normalized == "y" || normalized == "yes" {
-		return
+		return nil
 	}
 
-	ExitFail("Aborted.")
+	return ErrAborted
 }
 
-func MustGetUUID4String() string {
-	// does not match docs...
+func NewUUID4String() (string, error) {
 	u, err := uuid.NewV4()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
 	}
 
-	return u.String()
+	return u.String(), nil
 }
 
 func IsValidUUID4String(str string) bool {
@@ -69,33 +73,41 @@ func IsValidStatus(status string) bool {
 	return StrSliceContains(ALL_STATUSES, status)
 }
 
-func ParseDueDateArg(dueStr string) (dateFilter string, dueDate time.Time) {
+// ParseDueDateArg parses a due date operator, eg. due:friday,
+// due.before:+2w, due.within:7d or due:overdue.
+func ParseDueDateArg(dueStr string) (dateFilter string, dueDate time.Time, err error) {
 	parts := strings.SplitN(dueStr, ":", 2)
 	if len(parts) != 2 {
-		ExitFail("Invalid due query format: " + dueStr + "\n" +
-			"Expected format: due:YYYY-MM-DD, due:MM-DD, due:DD, due:next-monday, due:today, etc.")
+		return "", time.Time{}, fmt.Errorf("invalid due query format: %s\n"+
+			"Expected format: due:YYYY-MM-DD, due:MM-DD, due:DD, due:next-monday, due:today, etc.", dueStr)
 	}
 	if parts[1] == "overdue" {
-		dateFilter = "before"
-		dueDate = startOfDay(time.Now())
-		return dateFilter, dueDate
+		// matched against the time, not a date, see Task.matchesDue
+		return "overdue", time.Time{}, nil
 	}
 	tagParts := strings.SplitN(parts[0], ".", 2)
 	if len(tagParts) == 2 {
 		dateFilter = tagParts[1]
 
-		dateFilters := map[string]struct{}{"after": {}, "before": {}, "on": {}, "in": {}}
+		dateFilters := map[string]struct{}{"after": {}, "before": {}, "on": {}, "in": {}, "within": {}}
 		_, ok := dateFilters[dateFilter]
 		if !ok && dateFilter != "" {
-			ExitFail("Invalid date filter format: " + dateFilter + "\n" +
-				"Valid filters are: after, before, on, in")
+			return "", time.Time{}, fmt.Errorf("invalid date filter format: %s\n"+
+				"Valid filters are: after, before, on, in, within", dateFilter)
 		}
 
 	} else {
 		dateFilter = ""
 	}
-	dueDate = ParseStrToDate(parts[1])
-	return dateFilter, dueDate
+	dateStr := parts[1]
+	// due.within:7d is shorthand for due.within:+7d
+	if dateFilter == "within" && dateStr != "" && dateStr[0] >= '0' && dateStr[0] <= '9' {
+		if due, err := durationStrToTime(time.Now(), "+"+dateStr); err == nil && !due.IsZero() {
+			dateStr = "+" + dateStr
+		}
+	}
+	dueDate, err = ParseStrToDate(dateStr)
+	return dateFilter, dueDate, err
 }
 
 func SumInts(vals ...int) int {
@@ -163,8 +175,9 @@ func MakeTempFilename(id int, summary, ext string) string {
 	return fmt.Sprintf("dstask.*.%s.%s", loweredWithID, ext)
 }
 
-func MustEditBytes(data []byte, tmpFilename string) []byte {
-	editor := strings.Fields(os.Getenv("EDITOR"))
+// EditBytes edits data with the given editor command, see Config.Editor.
+func EditBytes(editorCmd string, data []byte, tmpFilename string) ([]byte, error) {
+	editor := strings.Fields(editorCmd)
 
 	if len(editor) == 0 {
 		editor = []string{"vim"}
@@ -172,7 +185,7 @@ func MustEditBytes(data []byte, tmpFilename string) []byte {
 
 	tmpfile, err := os.CreateTemp("", tmpFilename)
 	if err != nil {
-		ExitFail("Could not create temporary file to edit")
+		return nil, fmt.Errorf("could not create temporary file to edit: %w", err)
 	}
 
 	defer func() {
@@ -183,24 +196,24 @@ func MustEditBytes(data []byte, tmpFilename string) []byte {
 
 	_, err = tmpfile.Write(data)
 	if err != nil {
-		ExitFail("Could not write to temporary file to edit")
+		return nil, fmt.Errorf("could not write to temporary file to edit: %w", err)
 	}
 
 	if err := tmpfile.Close(); err != nil {
-		ExitFail("Could not close temporary file to edit")
+		return nil, fmt.Errorf("could not close temporary file to edit: %w", err)
 	}
 
 	err = RunCmd(editor[0], append(editor[1:], tmpfile.Name())...)
 	if err != nil {
-		ExitFail("Failed to run $EDITOR")
+		return nil, fmt.Errorf("failed to run editor %s: %w", editor[0], err)
 	}
 
 	data, err = os.ReadFile(tmpfile.Name())
 	if err != nil {
-		ExitFail("Could not read back temporary edited file")
+		return nil, fmt.Errorf("could not read back temporary edited file: %w", err)
 	}
 
-	return data
+	return data, nil
 }
 
 func StrSliceContains(haystack []string, needle string) bool {
@@ -233,7 +246,7 @@ func IsValidStateTransition(from string, to string) bool {
 	return false
 }
 
-func MustOpenBrowser(url string) {
+func OpenBrowser(url string) error {
 	var err error
 
 	switch runtime.GOOS {
@@ -244,12 +257,14 @@ func MustOpenBrowser(url string) {
 	case "darwin":
 		err = exec.Command("open", url).Start()
 	default:
-		ExitFail("unsupported platform")
+		return fmt.Errorf("opening a browser is not supported on %s", runtime.GOOS)
 	}
 
 	if err != nil {
-		ExitFail("Failed to open browser")
+		return fmt.Errorf("failed to open browser: %w", err)
 	}
+
+	return nil
 }
 
 func DeduplicateStrings(s []string) []string {
@@ -269,7 +284,7 @@ func DeduplicateStrings(s []string) []string {
 	return s[:j]
 }
 
-// MustGetTermSize is implemented per-OS in util_unix.go and util_windows.go
+// GetTermSize is implemented per-OS in util_unix.go and util_windows.go
 
 func StdoutIsTTY() bool {
 	isTTY := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
@@ -277,6 +292,52 @@ func StdoutIsTTY() bool {
 	return isTTY || FAKE_PTY
 }
 
+// temporary files of writeFileAtomic are named .<name>.tmp-<random>
+const TEMP_FILE_INFIX = ".tmp-"
+
+// writeFileAtomic writes data to a file, so that if interrupted, eg. by a
+// crash, the file is either as it was or as written, never truncated. The data
+// is written to a hidden temporary file in the same directory, which is then
+// renamed.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir, name := filepath.Split(path)
+
+	file, err := os.CreateTemp(dir, "."+name+TEMP_FILE_INFIX+"*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// clean up on failure, as well as can be done
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
 func WriteStdout(data []byte) error {
 	if _, err := os.Stdout.Write(data); err != nil {
 		return err
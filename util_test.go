@@ -2,6 +2,7 @@ package dstask
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -113,3 +114,21 @@ func TestStrSliceContainsAll(t *testing.T) {
 		assert.Equal(t, tc.expected, StrSliceContainsAll(tc.subset, tc.superset))
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "task.yml")
+
+	assert.NoError(t, writeFileAtomic(path, []byte("summary: a\n"), 0o600))
+	assert.NoError(t, writeFileAtomic(path, []byte("summary: b\n"), 0o600))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "summary: b\n", string(data))
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary files left")
+
+	assert.Error(t, writeFileAtomic(filepath.Join(dir, "missing", "task.yml"), nil, 0o600))
+}
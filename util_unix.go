@@ -3,20 +3,41 @@
 package dstask
 
 import (
+	"math"
 	"os"
 
 	"golang.org/x/sys/unix"
 )
 
-func MustGetTermSize() (int, int) {
+// GetTermSize returns the width and height of the terminal. Tables are only
+// written elsewhere with --format=table, so if stdout is not a terminal, the
+// size is as wide as tables get, without truncating rows.
+func GetTermSize() (int, int) {
 	if FAKE_PTY {
 		return 80, 24
 	}
 
 	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
 	if err != nil {
-		ExitFail("Not a TTY")
+		return TABLE_MAX_WIDTH, math.MaxInt32
 	}
 
 	return int(ws.Col), int(ws.Row)
 }
+
+// syncDir flushes changes to the entries of a directory to disk, eg. a file
+// renamed into it.
+func syncDir(dir string) (err error) {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return file.Sync()
+}
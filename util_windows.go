@@ -3,22 +3,25 @@
 package dstask
 
 import (
+	"math"
 	"os"
 
 	"github.com/mattn/go-isatty"
 	"golang.org/x/sys/windows"
 )
 
-func MustGetTermSize() (int, int) {
+// GetTermSize returns the width and height of the terminal. Tables are only
+// written elsewhere with --format=table, so if stdout is not a terminal, the
+// size is as wide as tables get, without truncating rows.
+func GetTermSize() (int, int) {
 	if FAKE_PTY {
 		return 80, 24
 	}
 
 	fd := os.Stdout.Fd()
 
-	// Fallback: if not a TTY, fail as before
 	if !(isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)) {
-		ExitFail("Not a TTY")
+		return TABLE_MAX_WIDTH, math.MaxInt32
 	}
 	
 	var info windows.ConsoleScreenBufferInfo
@@ -28,3 +31,9 @@ func MustGetTermSize() (int, int) {
 
 	return int(info.Window.Right - info.Window.Left + 1), int(info.Window.Bottom - info.Window.Top + 1)
 }
+
+// syncDir does nothing, as directories can't be synced on Windows. Renames are
+// journaled by NTFS.
+func syncDir(dir string) error {
+	return nil
+}